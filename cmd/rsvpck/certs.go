@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/adapters/httpx"
+	"github.com/azargarov/rsvpck/internal/config"
+)
+
+const (
+	cmdCerts            = "certs"
+	cmdCertsExport      = "export"
+	defaultCertsTimeout = 30 * time.Second
+)
+
+type certsExportConf struct {
+	target     string
+	serverName string
+	out        string
+	verifyWith string
+	proxies    []string // nil: the vpnIPs of the config
+	configPath string
+	timeout    time.Duration
+}
+
+func parseCertsExportFlags(args []string) (*certsExportConf, error) {
+	fs := flag.NewFlagSet(applicationName+" "+cmdCerts+" "+cmdCertsExport, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of rsvpck certs export:\n")
+		fmt.Fprintf(fs.Output(), "Fetches the certificate chain of a TLS server, directly or via the VPN\n")
+		fmt.Fprintf(fs.Output(), "proxies like the tls checks, and writes it in PEM as the server sent it.\n\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nExit codes: 0 chain written (and verified with -verify-with), %d handshake failed,\n", exitNotConnected)
+		fmt.Fprintf(fs.Output(), "%d chain does not verify against -verify-with, %d write failed, %d invalid flags.\n",
+			exitChainUntrusted, exitInternalError, exitConfigInvalid)
+	}
+
+	target := fs.String("target", "", "TLS server to fetch the chain from (host:port)")
+	serverName := fs.String("sni", "", "server name to send and verify. Default: host of -target")
+	out := fs.String("o", stdoutPath, "file to write the PEM chain to, - for stdout")
+	verifyWith := fs.String("verify-with", "", "PEM bundle of CA certificates to verify the chain against")
+	proxies := fs.String("proxies", "", "comma separated proxies to try when the direct handshake fails. Default: config 'vpnIPs'")
+	configPath := fs.String("config", "", "path to a YAML/JSON config file to take the proxies from")
+	timeout := fs.Duration("timeout", defaultCertsTimeout, "deadline of the handshakes")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errFlagsReported
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *target == "" {
+		return nil, fmt.Errorf("-target is required")
+	}
+	if _, _, err := net.SplitHostPort(*target); err != nil {
+		return nil, fmt.Errorf("-target: %w", err)
+	}
+	if *timeout <= 0 {
+		return nil, fmt.Errorf("-timeout must be greater than 0")
+	}
+	c := &certsExportConf{
+		target:     *target,
+		serverName: *serverName,
+		out:        *out,
+		verifyWith: *verifyWith,
+		configPath: *configPath,
+		timeout:    *timeout,
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "proxies" {
+			c.proxies = []string{} // set, an empty value means direct only
+			for _, p := range strings.Split(*proxies, ",") {
+				if p = strings.TrimSpace(p); p != "" {
+					c.proxies = append(c.proxies, p)
+				}
+			}
+		}
+	})
+	return c, nil
+}
+
+// runCerts dispatches the certs subcommands.
+func runCerts(args []string) int {
+	if len(args) == 0 || args[0] != cmdCertsExport {
+		fmt.Fprintf(os.Stderr, "Usage: rsvpck certs export -target host:port [-o chain.pem] [-verify-with ca.pem]\n")
+		return exitConfigInvalid
+	}
+	return runCertsExport(args[1:])
+}
+
+// runCertsExport writes the peer chain of the target in PEM, and with
+// -verify-with reports whether it verifies against the supplied bundle.
+func runCertsExport(args []string) int {
+	conf, err := parseCertsExportFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitDirect
+		}
+		if !errors.Is(err, errFlagsReported) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return exitConfigInvalid
+	}
+
+	var roots *x509.CertPool
+	if conf.verifyWith != "" {
+		if roots, err = loadCertPool(conf.verifyWith); err != nil {
+			fmt.Fprintf(os.Stderr, "-verify-with: %v\n", err)
+			return exitConfigInvalid
+		}
+	}
+	proxies := conf.proxies
+	if proxies == nil {
+		testConfig, _, err := config.Resolve(conf.configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+			return exitConfigInvalid
+		}
+		proxies = testConfig.VPNIPs
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.timeout)
+	defer cancel()
+	hs, err := httpx.GetCertificatesSmart(ctx, conf.target, conf.serverName, proxies, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "TLS handshake with %s failed: %v\n", conf.target, err)
+		return exitNotConnected
+	}
+
+	write := func(w io.Writer) error {
+		for _, cert := range hs.Certificates {
+			if err := pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if conf.out == stdoutPath {
+		err = write(os.Stdout)
+	} else {
+		err = writeFileAtomic(conf.out, write)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the chain to %s: %v\n", conf.out, err)
+		return exitInternalError
+	}
+	if conf.out != stdoutPath {
+		fmt.Fprintf(os.Stderr, "Wrote %d certificates of %s to %s\n", len(hs.Certificates), conf.target, conf.out)
+	}
+
+	if roots == nil {
+		return exitDirect
+	}
+	serverName := conf.serverName
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(conf.target)
+	}
+	if err := httpx.VerifyChain(hs.Certificates, serverName, roots); err != nil {
+		fmt.Fprintf(os.Stderr, "Chain does not verify against %s: %v\n", conf.verifyWith, err)
+		return exitChainUntrusted
+	}
+	fmt.Fprintf(os.Stderr, "Chain verifies against %s\n", conf.verifyWith)
+	return exitDirect
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM certificates in %s", path)
+	}
+	return pool, nil
+}
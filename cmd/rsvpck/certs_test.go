@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, path string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// selfSignedCA returns the DER of a CA that signed none of the httptest
+// certificates.
+func selfSignedCA(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Other CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestRunCertsExport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	target := srv.Listener.Addr().String()
+
+	dir := t.TempDir()
+	trusted := filepath.Join(dir, "trusted.pem")
+	writePEM(t, trusted, srv.Certificate().Raw)
+	untrusted := filepath.Join(dir, "untrusted.pem")
+	writePEM(t, untrusted, selfSignedCA(t))
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("no certificates"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := ln.Addr().String()
+	_ = ln.Close()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"export", []string{"-target", target}, exitDirect},
+		{"verifies", []string{"-target", target, "-verify-with", trusted}, exitDirect},
+		{"other CA", []string{"-target", target, "-verify-with", untrusted}, exitChainUntrusted},
+		{"handshake fails", []string{"-target", down}, exitNotConnected},
+		{"bundle without certificates", []string{"-target", target, "-verify-with", empty}, exitConfigInvalid},
+		{"missing target", []string{}, exitConfigInvalid},
+		{"target without port", []string{"-target", "example.com"}, exitConfigInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "chain.pem")
+			args := append([]string{"-proxies", "", "-timeout", "5s", "-o", out}, tt.args...)
+			if got := runCertsExport(args); got != tt.want {
+				t.Fatalf("runCertsExport(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+			if tt.want != exitDirect && tt.want != exitChainUntrusted {
+				return
+			}
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block, _ := pem.Decode(data)
+			if block == nil || block.Type != "CERTIFICATE" {
+				t.Fatalf("%s holds no PEM certificate:\n%s", out, data)
+			}
+			if string(block.Bytes) != string(srv.Certificate().Raw) {
+				t.Error("the first PEM block is not the server leaf")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// Process exit codes. This is a contract with monitoring wrappers,
+// existing values must not change.
+const (
+	exitDirect         = 0  // connected, direct internet
+	exitViaProxy       = 10 // connected via proxy
+	exitViaVPN         = 11 // connected via VPN
+	exitNotConnected   = 20 // no connectivity
+	exitModeRejected   = 21 // connected, but the mode is listed in -fail-on
+	exitChainUntrusted = 30 // certs export: the chain does not verify against -verify-with
+	exitInternalError  = 70 // unexpected failure, e.g. rendering the report
+	exitConfigInvalid  = 78 // invalid flags or configuration
+)
+
+const exitCodesUsage = `Exit codes:
+   0  connected, direct internet
+  10  connected via proxy
+  11  connected via VPN
+  20  not connected
+  21  connected, but the mode is listed in -fail-on
+  30  certs export: the chain does not verify against -verify-with
+  70  internal error
+  78  invalid flags or configuration
+`
+
+func exitCodeFor(mode domain.ConnectivityMode, failOn []domain.ConnectivityMode) int {
+	for _, m := range failOn {
+		if m == mode && mode.IsConnected() {
+			return exitModeRejected
+		}
+	}
+	switch mode {
+	case domain.ModeDirect:
+		return exitDirect
+	case domain.ModeViaProxy:
+		return exitViaProxy
+	case domain.ModeViaVPN:
+		return exitViaVPN
+	default:
+		return exitNotConnected
+	}
+}
+
+// parseFailOn parses a comma separated list of modes: direct, proxy, vpn.
+func parseFailOn(s string) ([]domain.ConnectivityMode, error) {
+	var modes []domain.ConnectivityMode
+	for _, item := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(item)) {
+		case "":
+		case "direct":
+			modes = append(modes, domain.ModeDirect)
+		case "proxy", "via_proxy":
+			modes = append(modes, domain.ModeViaProxy)
+		case "vpn", "via_vpn":
+			modes = append(modes, domain.ModeViaVPN)
+		default:
+			return nil, fmt.Errorf("-fail-on: unknown mode %q, expected direct, proxy or vpn", item)
+		}
+	}
+	return modes, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	tests := []struct {
+		mode   domain.ConnectivityMode
+		failOn string
+		want   int
+	}{
+		{domain.ModeDirect, "", exitDirect},
+		{domain.ModeViaProxy, "", exitViaProxy},
+		{domain.ModeViaVPN, "", exitViaVPN},
+		{domain.ModeNone, "", exitNotConnected},
+
+		{domain.ModeDirect, "direct", exitModeRejected},
+		{domain.ModeDirect, "proxy,vpn", exitDirect},
+		{domain.ModeViaProxy, "proxy", exitModeRejected},
+		{domain.ModeViaProxy, "via_proxy", exitModeRejected},
+		{domain.ModeViaProxy, "direct", exitViaProxy},
+		{domain.ModeViaVPN, "vpn", exitModeRejected},
+		{domain.ModeViaVPN, " Direct , VIA_VPN ", exitModeRejected},
+		{domain.ModeViaVPN, "direct,proxy", exitViaVPN},
+		// not connected stays 20, -fail-on only lists connected modes
+		{domain.ModeNone, "direct,proxy,vpn", exitNotConnected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String()+"/"+tt.failOn, func(t *testing.T) {
+			failOn, err := parseFailOn(tt.failOn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := exitCodeFor(tt.mode, failOn); got != tt.want {
+				t.Errorf("exitCodeFor(%s, %q) = %d, want %d", tt.mode, tt.failOn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFailOnRejectsUnknownMode(t *testing.T) {
+	for _, s := range []string{"none", "direct,tor", "proxy;vpn"} {
+		if _, err := parseFailOn(s); err == nil {
+			t.Errorf("parseFailOn(%q) = nil error, want unknown mode", s)
+		}
+	}
+}
+
+// Invalid flags end the run before any probe is sent.
+func TestRunInvalidFlagsExitCode(t *testing.T) {
+	for _, args := range [][]string{
+		{"-fail-on", "tor"},
+		{"-format", "yaml"},
+		{"-parallel", "-1"},
+		{"-no-such-flag"},
+	} {
+		if got := run(args); got != exitConfigInvalid {
+			t.Errorf("run(%q) = %d, want %d", args, got, exitConfigInvalid)
+		}
+	}
+}
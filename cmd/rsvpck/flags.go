@@ -1,51 +1,197 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/azargarov/rsvpck/internal/adapters/icmp"
+	tmplrender "github.com/azargarov/rsvpck/internal/adapters/render/template"
+	"github.com/azargarov/rsvpck/internal/domain"
 )
 
+const (
+	formatTable = "table"
+	formatText  = "text"
+	formatJSON  = "json"
+	formatJUnit = "junit"
+	formatMD    = "md"
+	formatHTML  = "html"
+	formatTmpl  = "template"
+)
+
+var errFlagsReported = errors.New("invalid command line")
+
 type rsvpckConf struct {
-	textRender  	bool
-	tableRender 	bool
+	format 			string
+	configPath		string
+	parallel		int
+	policy			string
+	failOn			[]domain.ConnectivityMode
+	outputs			[]output
+	template		*tmplrender.Template
+	icmpMode		string
+	deadline		time.Duration
 	forceASCII 		bool
 	//speedtest  		bool
 	printVersion	bool
 }
 
 func NewRsvpckConf() rsvpckConf {
-	return rsvpckConf{tableRender: true}
+	return rsvpckConf{format: formatTable, deadline: defaultDeadline}
 }
 
-func (r *rsvpckConf) setTextRenderOn() {
-	r.tableRender = false
-	r.textRender = true
+func (r *rsvpckConf) SetRender(textRender bool) {
+	if textRender {
+		r.format = formatText
+		return
+	}
+	r.format = formatTable
 }
 
-func (r *rsvpckConf) setTextRenderOff() {
-	r.tableRender = true
-	r.textRender = false
+// interactive reports whether stdout is meant for a human on a terminal, so
+// header, spinner and system information may be printed around the report.
+// It is not when stdout gets a machine readable format.
+func (r *rsvpckConf) interactive() bool {
+	if len(r.outputs) == 0 {
+		return r.format == formatTable || r.format == formatText
+	}
+	toStdout := false
+	for _, o := range r.outputs {
+		if o.path != stdoutPath {
+			continue
+		}
+		if o.format != formatTable && o.format != formatText {
+			return false
+		}
+		toStdout = true
+	}
+	return toStdout
 }
 
-func (r *rsvpckConf) SetRender(textRender bool) {
-	if textRender {
-		r.setTextRenderOn()
-		return
+func (r *rsvpckConf) usesFormat(format string) bool {
+	for _, o := range r.outputs {
+		if o.format == format {
+			return true
+		}
 	}
-	r.setTextRenderOff()
+	return false
 }
 
-func parseFlagsToConfig() *rsvpckConf {
-	txtRender := flag.Bool("text", false, "render connectivity info as text. Default table")
-	flagForceASCII := flag.Bool("ascii", false, "Force ASCII-only output (no Unicode symbols)")
-	//speedtestFlag := flag.Bool("speedtest", false, "Run optional speedtest")
-	printVersion := flag.Bool("version", false, "Print version")
-	flag.Parse()
+// parseFlagsToConfig parses the command line. It returns flag.ErrHelp when
+// usage was requested and errFlagsReported when the flag package has already
+// printed the problem.
+func parseFlagsToConfig(args []string) (*rsvpckConf, error) {
+	fs := flag.NewFlagSet(applicationName, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of rsvpck:\n")
+		fmt.Fprintf(fs.Output(), "  rsvpck [flags]          run the checks once\n")
+		fmt.Fprintf(fs.Output(), "  rsvpck watch [flags]    rerun the checks periodically, see rsvpck watch -h\n")
+		fmt.Fprintf(fs.Output(), "  rsvpck serve [flags]    serve the checks over a local HTTP API, see rsvpck serve -h\n")
+		fmt.Fprintf(fs.Output(), "  rsvpck metrics [flags]  export Prometheus metrics, see rsvpck metrics -h\n")
+		fmt.Fprintf(fs.Output(), "  rsvpck certs export     write the certificate chain of a server in PEM, see rsvpck certs export -h\n\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\n%s", exitCodesUsage)
+	}
+
+	txtRender := fs.Bool("text", false, "render connectivity info as text. Default table")
+	format := fs.String("format", "", "stdout format: "+strings.Join(formatNames(), ", ")+". Default table")
+	failOn := fs.String("fail-on", "", "comma separated connected modes to treat as failure (exit 21): direct, proxy, vpn")
+	var outputs outputList
+	fs.Var(&outputs, "o", "write the report as format=path, repeatable; path - is stdout, e.g. -o table=- -o json=run.json.\n"+
+		"A bare path picks the format from its extension, e.g. -o report.html. Stdout keeps -format unless an -o targets it")
+	templatePath := fs.String("template", "", "render with this Go text/template file, used for stdout unless -format is set; -o template=path writes it to a file")
+	run := registerRunFlags(fs)
+	//speedtestFlag := fs.Bool("speedtest", false, "Run optional speedtest")
+	printVersion := fs.Bool("version", false, "Print version")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		// the flag package has already reported the error together with usage
+		return nil, errFlagsReported
+	}
 
 	r := NewRsvpckConf()
 	r.SetRender(*txtRender)
-	r.forceASCII = *flagForceASCII
+	switch *format {
+	case "":
+		if *templatePath != "" && !*txtRender {
+			r.format = formatTmpl
+		}
+	default:
+		if _, ok := renderers[*format]; !ok {
+			return nil, fmt.Errorf("unknown output format %q", *format)
+		}
+		r.format = *format
+	}
+	modes, err := parseFailOn(*failOn)
+	if err != nil {
+		return nil, err
+	}
+	r.failOn = modes
+	if r.outputs, err = resolveOutputs(r.format, outputs); err != nil {
+		return nil, err
+	}
+	if *templatePath != "" {
+		if r.template, err = tmplrender.ParseFile(*templatePath); err != nil {
+			return nil, fmt.Errorf("-template: %w", err)
+		}
+	} else if r.usesFormat(formatTmpl) {
+		return nil, fmt.Errorf("format %q needs -template", formatTmpl)
+	}
+	if err := run.apply(&r); err != nil {
+		return nil, err
+	}
 	//r.speedtest = *speedtestFlag
 	r.printVersion = *printVersion
-	return &r
+	return &r, nil
+}
+
+// runFlags are the flags shared by every command that runs the checks.
+type runFlags struct {
+	configPath *string
+	parallel   *int
+	policy     *string
+	icmpMode   *string
+	deadline   *time.Duration
+	forceASCII *bool
+}
+
+func registerRunFlags(fs *flag.FlagSet) *runFlags {
+	return &runFlags{
+		configPath: fs.String("config", "", "path to a YAML/JSON config file. Default: $RSVPCK_CONFIG, ./rsvpck.yaml, $XDG_CONFIG_HOME/rsvpck, /etc/rsvpck, embedded"),
+		parallel:   fs.Int("parallel", 0, "max number of probes running at the same time per group. Default: config 'concurrency' or 4"),
+		policy:     fs.String("policy", "", "execution policy: optimized or exhaustive. Default: $RSVPCK_POLICY, config 'policy' or exhaustive"),
+		icmpMode:   fs.String("icmp", "", "ICMP implementation: auto, native (sockets) or exec (ping binary). Default: config 'icmpMode' or auto"),
+		deadline:   fs.Duration("deadline", defaultDeadline, "overall deadline of the run, probes still running are reported as timed out"),
+		forceASCII: fs.Bool("ascii", false, "Force ASCII-only output (no Unicode symbols)"),
+	}
+}
+
+// apply validates the parsed values and stores them in r.
+func (f *runFlags) apply(r *rsvpckConf) error {
+	r.configPath = *f.configPath
+	if *f.parallel < 0 {
+		return fmt.Errorf("-parallel must not be negative")
+	}
+	r.parallel = *f.parallel
+	if *f.policy != "" {
+		if _, ok := domain.ParseExecutionPolicy(*f.policy); !ok {
+			return fmt.Errorf("unknown policy %q", *f.policy)
+		}
+	}
+	r.policy = *f.policy
+	if _, ok := icmp.ParseMode(*f.icmpMode); !ok {
+		return fmt.Errorf("unknown ICMP mode %q", *f.icmpMode)
+	}
+	r.icmpMode = *f.icmpMode
+	if *f.deadline <= 0 {
+		return fmt.Errorf("-deadline must be greater than 0")
+	}
+	r.deadline = *f.deadline
+	r.forceASCII = *f.forceASCII
+	return nil
 }
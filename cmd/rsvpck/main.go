@@ -9,12 +9,15 @@ import (
 	"github.com/azargarov/rsvpck/internal/adapters/icmp"
 	"github.com/azargarov/rsvpck/internal/adapters/render/text"
 	"github.com/azargarov/rsvpck/internal/adapters/tcp"
+	"github.com/azargarov/rsvpck/internal/adapters/udp"
 	"github.com/azargarov/rsvpck/internal/config"
 	"github.com/azargarov/rsvpck/internal/app"
 	"github.com/azargarov/rsvpck/internal/domain"
 	//"github.com/azargarov/rsvpck/internal/adapters/speedtest"
 
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"time"
@@ -22,30 +25,69 @@ import (
 	"runtime"
 	"bufio"
 )
-//TODO: if TLS is stuck thre rest fails due to timeout.
 var version = "dev"
 
 const (
-    applicationName = "RSvP connectivity checker"
-	totalTimeout = 300*time.Second
+	applicationName = "RSvP connectivity checker"
+	defaultDeadline = 300*time.Second
+	defaultParallel = 4
+	defaultPolicy = domain.PolicyExhaustive
+	envPolicy = "RSVPCK_POLICY"
 )
 
 func main() {
-	
-	rsvpConf := parseFlagsToConfig()
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case cmdWatch:
+			return runWatch(args[1:])
+		case cmdServe:
+			return runServe(args[1:])
+		case cmdMetrics:
+			return runMetrics(args[1:])
+		case cmdCerts:
+			return runCerts(args[1:])
+		}
+	}
+
+	rsvpConf, err := parseFlagsToConfig(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitDirect
+		}
+		if !errors.Is(err, errFlagsReported) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return exitConfigInvalid
+	}
 	if rsvpConf.printVersion{
 		fmt.Printf("%s, version %s\n", applicationName, version)
-		return
+		return exitDirect
 	}
 
-	printHeader()
-	
+	interactive := rsvpConf.interactive()
+
+	testConfig, configSource, err := config.Resolve(rsvpConf.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+	executor, err := newExecutor(rsvpConf, testConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+
+	if interactive {
+		printHeader(configSource)
+	}
 
-	var renderer domain.Renderer
 	renderConf := text.NewRenderConfig(text.WithForceASCII(rsvpConf.forceASCII))
-	
-	var err error
-	ctx, cancel := context.WithTimeout(context.Background(), totalTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), rsvpConf.deadline)
 	defer cancel()
 
 	//if rsvpConf.speedtest{
@@ -56,53 +98,104 @@ func main() {
 	//	return
 	//}
 
-	testConfig, err := config.LoadEmbedded()
-	if err != nil {
-		fmt.Printf("Invalid config: %v", err)
-		return
-	}
-	
 	h := hostinfo.GetCRMInfo(ctx)
 	autostrCfg := autostr.Config{Separator: autostr.Ptr("\n"), FieldValueSeparator: autostr.Ptr(" : "), PrettyPrint: true}
 
-	stopSpinner := startAnimatedSpinner(os.Stdout, ctx, 120 * time.Millisecond)	
+	stopSpinner := startSpinner(interactive, ctx)
 
-	text.PrintBlock(os.Stdout, "SYSTEM INFORMATION", autostr.String(h, autostrCfg), renderConf)
-	h.TLSCert, err = httpx.GetCertificatesSmart(ctx, "insite-eu.gehealthcare.com:443", "insite-eu.gehealthcare.com", testConfig.VPNIPs)
+	result := executor.Run(ctx, testConfig)
 
 	stopSpinner()
 
-	if err == nil {
-		text.PrintList(os.Stdout, "TLS certificates, eu-insite.gehealthcare.com\n", h.TLSCert, renderConf)
-	} else {
-		fmt.Println("Failed fetching certificates")
+	// printed after the run, it includes the negotiated TLS session
+	h.SetTLS(result.CertificateChain(), result.TLSSession())
+	if interactive {
+		text.PrintBlock(os.Stdout, "SYSTEM INFORMATION", autostr.String(h, autostrCfg), renderConf)
+		printCertificates(result, renderConf)
 	}
 
-	tcpChecker := &tcp.Checker{}
-	dnsChecker := &dns.Checker{}
-	httpChecker := &http.Checker{}
-	icmpChecker := &icmp.Checker{}
-
+	code := exitCodeFor(result.Mode, rsvpConf.failOn)
+	env := renderEnv{renderConf: renderConf, host: h, source: configSource, template: rsvpConf.template}
+	if err := writeOutputs(rsvpConf.outputs, result, env); err != nil {
+		code = exitInternalError
+	}
+	if interactive {
+		waitForEnterOnWindows()
+	}
+	return code
+}
 
-	stopSpinner = startAnimatedSpinner(os.Stdout, ctx, 120 * time.Millisecond)
+// newExecutor wires the checkers with the settings resolved from flags,
+// environment and config.
+func newExecutor(rsvpConf *rsvpckConf, testConfig domain.NetTestConfig) (*app.Executor, error) {
+	policy, err := resolvePolicy(rsvpConf.policy, testConfig.Policy)
+	if err != nil {
+		return nil, err
+	}
 
-	executor := app.NewExecutor(tcpChecker, dnsChecker, httpChecker, icmpChecker, domain.PolicyExhaustive)
-	result := executor.Run(ctx, testConfig)
+	icmpMode := rsvpConf.icmpMode
+	if icmpMode == "" {
+		icmpMode = testConfig.ICMPMode
+	}
+	mode, _ := icmp.ParseMode(icmpMode)
 
-	stopSpinner()
+	return app.NewExecutor(&tcp.Checker{}, &dns.Checker{}, &http.Checker{}, &icmp.Checker{Mode: mode}, policy,
+		app.WithConcurrency(resolveParallel(rsvpConf.parallel, testConfig.Concurrency)),
+		app.WithTLSChecker(&httpx.TLSChecker{}),
+		app.WithUDPChecker(&udp.Checker{})), nil
+}
 
-	if rsvpConf.textRender {
-		renderer = text.NewRenderer(renderConf)
-		if err := renderer.Render(os.Stdout, result); err != nil {
-			fmt.Printf("Failed to render: %v", err)
+func printCertificates(result domain.ConnectivityResult, renderConf *text.RenderConfig) {
+	for _, p := range result.Probes {
+		if !p.Endpoint.IsTLS() {
+			continue
 		}
-	} else {
-		renderer = text.NewTableRenderer(renderConf)
-		if err := renderer.Render(os.Stdout, result); err != nil {
-			fmt.Printf("Failed to render: %v", err)
+		if len(p.Certificates) == 0 {
+			fmt.Printf("Failed fetching certificates, %s\n", p.Endpoint.Target)
+			continue
 		}
+		text.PrintList(os.Stdout, fmt.Sprintf("TLS certificates, %s\n", p.Endpoint.Target), p.Certificates, renderConf)
+	}
+}
+
+// resolveParallel picks the worker count: flag first, then config, then default.
+func resolveParallel(flagValue, configValue int) int {
+	switch {
+	case flagValue > 0:
+		return flagValue
+	case configValue > 0:
+		return configValue
+	default:
+		return defaultParallel
+	}
+}
+
+// resolvePolicy applies flag > env > config file > default precedence.
+func resolvePolicy(flagValue string, configValue *domain.ExecutionPolicy) (domain.ExecutionPolicy, error) {
+	if flagValue != "" {
+		p, _ := domain.ParseExecutionPolicy(flagValue)
+		return p, nil
+	}
+	if v := os.Getenv(envPolicy); v != "" {
+		p, ok := domain.ParseExecutionPolicy(v)
+		if !ok {
+			return defaultPolicy, fmt.Errorf("$%s: unknown policy %q", envPolicy, v)
+		}
+		return p, nil
+	}
+	if configValue != nil {
+		return *configValue, nil
+	}
+	return defaultPolicy, nil
+}
+
+// startSpinner starts the progress animation only for interactive output,
+// machine readable formats must not get escape sequences on stdout.
+func startSpinner(enabled bool, ctx context.Context) (stop func()) {
+	if !enabled {
+		return func() {}
 	}
-	waitForEnterOnWindows()
+	return startAnimatedSpinner(os.Stdout, ctx, 120 * time.Millisecond)
 }
 
 func startAnimatedSpinner(w io.Writer, parent context.Context, interval time.Duration) (stop func()) {
@@ -140,9 +233,10 @@ func startAnimatedSpinner(w io.Writer, parent context.Context, interval time.Dur
 	}
 }
 
-func printHeader() {
+func printHeader(src config.Source) {
 	fmt.Println("\nRSVP CHECK - Connectivity Diagnostics")
 	fmt.Println("-------------------------------------")
+	fmt.Printf("Config: %s\n\n", src)
 }
 
 func waitForEnterOnWindows() {
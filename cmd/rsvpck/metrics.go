@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	promrender "github.com/azargarov/rsvpck/internal/adapters/render/prom"
+	"github.com/azargarov/rsvpck/internal/app"
+	"github.com/azargarov/rsvpck/internal/config"
+)
+
+const (
+	cmdMetrics             = "metrics"
+	defaultMetricsListen   = ":9181"
+	defaultMetricsInterval = 60 * time.Second
+	metricsContentType     = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+type metricsConf struct {
+	rsvpckConf
+	listen   string
+	interval time.Duration
+	textfile string
+}
+
+func parseMetricsFlags(args []string) (*metricsConf, error) {
+	fs := flag.NewFlagSet(applicationName+" "+cmdMetrics, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of rsvpck metrics:\n")
+		fmt.Fprintf(fs.Output(), "Reruns the checks every interval and serves the results on /metrics,\n")
+		fmt.Fprintf(fs.Output(), "or with -textfile runs once and writes a node_exporter textfile.\n\n")
+		fs.PrintDefaults()
+	}
+
+	listen := fs.String("listen", defaultMetricsListen, "address to serve /metrics on")
+	interval := fs.Duration("interval", defaultMetricsInterval, "time between the start of two runs")
+	textfile := fs.String("textfile", "", "run once and write the metrics to this file (e.g. /var/lib/node_exporter/rsvpck.prom)")
+	run := registerRunFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errFlagsReported
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	m := &metricsConf{rsvpckConf: NewRsvpckConf()}
+	if err := run.apply(&m.rsvpckConf); err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(*listen); err != nil {
+		return nil, fmt.Errorf("-listen: %w", err)
+	}
+	m.listen = *listen
+	if *interval <= 0 {
+		return nil, fmt.Errorf("-interval must be greater than 0")
+	}
+	m.interval = *interval
+	m.textfile = *textfile
+	return m, nil
+}
+
+// runMetrics serves Prometheus metrics until SIGINT/SIGTERM, or writes them
+// once to a textfile.
+func runMetrics(args []string) int {
+	metricsConf, err := parseMetricsFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitDirect
+		}
+		if !errors.Is(err, errFlagsReported) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return exitConfigInvalid
+	}
+
+	testConfig, configSource, err := config.Resolve(metricsConf.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+	executor, err := newExecutor(&metricsConf.rsvpckConf, testConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	renderer := promrender.NewRenderer()
+	if metricsConf.textfile != "" {
+		runCtx, cancel := context.WithTimeout(ctx, metricsConf.deadline)
+		defer cancel()
+		result := executor.Run(runCtx, testConfig)
+		err := writeFileAtomic(metricsConf.textfile, func(w io.Writer) error {
+			return renderer.Render(w, result)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", metricsConf.textfile, err)
+			return exitInternalError
+		}
+		return exitDirect
+	}
+
+	history := app.NewHistory(1)
+	watcher := app.NewWatcher(executor, testConfig, metricsConf.interval, metricsConf.deadline, history)
+	go watcher.Run(ctx, nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		result, ok := history.Latest()
+		if !ok {
+			http.Error(w, "first run has not finished yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", metricsContentType)
+		if err := renderer.Render(w, result); err != nil {
+			log.Printf("metrics: %v", err)
+		}
+	})
+	srv := &http.Server{
+		Addr:              metricsConf.listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("%s %s serving metrics on %s/metrics every %s (config: %s)",
+		applicationName, version, metricsConf.listen, metricsConf.interval, configSource)
+
+	select {
+	case err := <-errc:
+		log.Printf("metrics: %v", err)
+		return exitInternalError
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+		return exitInternalError
+	}
+	return exitDirect
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	htmlrender "github.com/azargarov/rsvpck/internal/adapters/render/html"
+	jsonrender "github.com/azargarov/rsvpck/internal/adapters/render/json"
+	junitrender "github.com/azargarov/rsvpck/internal/adapters/render/junit"
+	mdrender "github.com/azargarov/rsvpck/internal/adapters/render/markdown"
+	tmplrender "github.com/azargarov/rsvpck/internal/adapters/render/template"
+	"github.com/azargarov/rsvpck/internal/adapters/render/text"
+	"github.com/azargarov/rsvpck/internal/config"
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// stdoutPath as the path of an output means standard output.
+const stdoutPath = "-"
+
+// renderEnv is what a renderer may need besides the result itself.
+type renderEnv struct {
+	renderConf *text.RenderConfig
+	host       domain.HostInfo
+	source     config.Source
+	template   *tmplrender.Template
+}
+
+type rendererFactory func(env renderEnv) domain.Renderer
+
+// renderers maps the format names accepted by -format and -o to their
+// renderer. A new domain.Renderer only needs an entry here.
+var renderers = map[string]rendererFactory{
+	formatTable: func(env renderEnv) domain.Renderer { return text.NewTableRenderer(env.renderConf) },
+	formatText:  func(env renderEnv) domain.Renderer { return text.NewRenderer(env.renderConf) },
+	formatJSON: func(env renderEnv) domain.Renderer {
+		return jsonrender.NewRenderer(jsonrender.WithHostInfo(env.host), jsonrender.WithConfigSource(env.source.String()))
+	},
+	formatJUnit: func(env renderEnv) domain.Renderer {
+		return junitrender.NewRenderer(junitrender.WithHostInfo(env.host))
+	},
+	formatMD: func(env renderEnv) domain.Renderer {
+		return mdrender.NewRenderer(mdrender.WithHostInfo(env.host), mdrender.WithConfigSource(env.source.String()))
+	},
+	formatHTML: func(env renderEnv) domain.Renderer {
+		return htmlrender.NewRenderer(htmlrender.WithHostInfo(env.host),
+			htmlrender.WithConfigSource(env.source.String()),
+			htmlrender.WithGenerator(fmt.Sprintf("%s %s", applicationName, version)))
+	},
+	formatTmpl: func(env renderEnv) domain.Renderer {
+		return tmplrender.NewRenderer(env.template, tmplrender.WithRenderConfig(env.renderConf),
+			tmplrender.WithHostInfo(env.host), tmplrender.WithConfigSource(env.source.String()))
+	},
+}
+
+// extensionFormats infers the format of a bare -o path, as in -o report.html.
+var extensionFormats = map[string]string{
+	".html": formatHTML,
+	".htm":  formatHTML,
+	".json": formatJSON,
+	".xml":  formatJUnit,
+	".md":   formatMD,
+	".txt":  formatText,
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// output is one destination of the report, path is stdoutPath or a file.
+type output struct {
+	format string
+	path   string
+}
+
+// outputList collects repeated -o flags.
+type outputList []output
+
+func (l *outputList) String() string {
+	parts := make([]string, 0, len(*l))
+	for _, o := range *l {
+		parts = append(parts, o.format+"="+o.path)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set accepts format=path, or a bare path whose format follows from its
+// extension. A "=" after a directory or file name is part of a bare path.
+func (l *outputList) Set(v string) error {
+	if v == "" {
+		return fmt.Errorf("empty output")
+	}
+	if format, path, ok := strings.Cut(v, "="); ok && !strings.ContainsAny(format, `./\`) {
+		if _, known := renderers[format]; !known {
+			return fmt.Errorf("output %q: unknown format %q, expected one of: %s",
+				v, format, strings.Join(formatNames(), ", "))
+		}
+		if path == "" {
+			return fmt.Errorf("output %q has no path, use %s=- for stdout", v, format)
+		}
+		*l = append(*l, output{format: format, path: path})
+		return nil
+	}
+	format, ok := extensionFormats[strings.ToLower(filepath.Ext(v))]
+	if !ok {
+		return fmt.Errorf("cannot infer the format of %q, use format=path with one of: %s",
+			v, strings.Join(formatNames(), ", "))
+	}
+	*l = append(*l, output{format: format, path: v})
+	return nil
+}
+
+// resolveOutputs sends the -format output to stdout unless an -o already
+// targets it, and rejects stdout or files named twice.
+func resolveOutputs(format string, outputs outputList) ([]output, error) {
+	seen := make(map[string]bool, len(outputs))
+	toStdout := false
+	for _, o := range outputs {
+		if o.path == stdoutPath {
+			if toStdout {
+				return nil, fmt.Errorf("more than one output to stdout")
+			}
+			toStdout = true
+			continue
+		}
+		p := filepath.Clean(o.path)
+		if seen[p] {
+			return nil, fmt.Errorf("output file %s given more than once", o.path)
+		}
+		seen[p] = true
+	}
+	if toStdout {
+		return outputs, nil
+	}
+	return append([]output{{format: format, path: stdoutPath}}, outputs...), nil
+}
+
+// writeOutputs renders the one result to every output. Files are replaced
+// atomically; all outputs are attempted even if one fails.
+func writeOutputs(outputs []output, result domain.ConnectivityResult, env renderEnv) error {
+	var failed error
+	for _, o := range outputs {
+		renderer := renderers[o.format](env)
+		var err error
+		if o.path == stdoutPath {
+			err = renderer.Render(os.Stdout, result)
+		} else {
+			err = writeFileAtomic(o.path, func(w io.Writer) error {
+				return renderer.Render(w, result)
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write %s output to %s: %v\n", o.format, outputName(o.path), err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+func outputName(path string) string {
+	if path == stdoutPath {
+		return "stdout"
+	}
+	return path
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOutputListSet(t *testing.T) {
+	tests := []struct {
+		flags   []string
+		want    []output
+		wantErr string
+	}{
+		{flags: []string{"json=run.json"}, want: []output{{formatJSON, "run.json"}}},
+		{flags: []string{"table=-", "junit=results.xml"}, want: []output{{formatTable, "-"}, {formatJUnit, "results.xml"}}},
+		{flags: []string{"report.html"}, want: []output{{formatHTML, "report.html"}}},
+		{flags: []string{"out/REPORT.MD"}, want: []output{{formatMD, "out/REPORT.MD"}}},
+		{flags: []string{"results.xml", "run.json"}, want: []output{{formatJUnit, "results.xml"}, {formatJSON, "run.json"}}},
+		// the explicit format wins over the extension
+		{flags: []string{"json=report.html"}, want: []output{{formatJSON, "report.html"}}},
+		// "=" in a directory or file name is part of a bare path
+		{flags: []string{"out/a=b.json"}, want: []output{{formatJSON, "out/a=b.json"}}},
+		{flags: []string{"run=1.json"}, wantErr: `unknown format "run"`},
+		{flags: []string{"xml=out.json"}, wantErr: `unknown format "xml"`},
+		{flags: []string{"json="}, wantErr: "has no path"},
+		{flags: []string{"report.pdf"}, wantErr: "cannot infer the format"},
+		{flags: []string{"report"}, wantErr: "cannot infer the format"},
+		{flags: []string{""}, wantErr: "empty output"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.flags, " "), func(t *testing.T) {
+			var l outputList
+			var err error
+			for _, f := range tt.flags {
+				if err = l.Set(f); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Set = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(l, tt.want) {
+				t.Errorf("outputs = %v, want %v", l, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		outputs outputList
+		want    []output
+		wantErr string
+	}{
+		{
+			name:   "no -o writes -format to stdout",
+			format: formatTable,
+			want:   []output{{formatTable, "-"}},
+		},
+		{
+			name:    "files are written besides stdout",
+			format:  formatMD,
+			outputs: outputList{{formatJSON, "run.json"}},
+			want:    []output{{formatMD, "-"}, {formatJSON, "run.json"}},
+		},
+		{
+			name:    "-o to stdout replaces -format",
+			format:  formatTable,
+			outputs: outputList{{formatJSON, "-"}, {formatHTML, "r.html"}},
+			want:    []output{{formatJSON, "-"}, {formatHTML, "r.html"}},
+		},
+		{
+			name:    "same file twice",
+			format:  formatTable,
+			outputs: outputList{{formatJSON, "out/run.json"}, {formatJUnit, "out/../out/run.json"}},
+			wantErr: "given more than once",
+		},
+		{
+			name:    "stdout twice",
+			format:  formatTable,
+			outputs: outputList{{formatJSON, "-"}, {formatTable, "-"}},
+			wantErr: "more than one output to stdout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveOutputs(tt.format, tt.outputs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveOutputs = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("outputs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
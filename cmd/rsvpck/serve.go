@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/adapters/hostinfo"
+	"github.com/azargarov/rsvpck/internal/adapters/httpapi"
+	"github.com/azargarov/rsvpck/internal/config"
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+const (
+	cmdServe          = "serve"
+	defaultListenAddr = "127.0.0.1:9180"
+	shutdownTimeout   = 10 * time.Second
+)
+
+type serveConf struct {
+	rsvpckConf
+	listen      string
+	keep        int
+	allowInline bool
+}
+
+func parseServeFlags(args []string) (*serveConf, error) {
+	fs := flag.NewFlagSet(applicationName+" "+cmdServe, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of rsvpck serve:\n")
+		fmt.Fprintf(fs.Output(), "Serves the checks over HTTP:\n")
+		fmt.Fprintf(fs.Output(), "  POST /v1/run             run the checks, YAML/JSON config in the body with -allow-inline-config\n")
+		fmt.Fprintf(fs.Output(), "  GET  /v1/results/latest  most recent result\n")
+		fmt.Fprintf(fs.Output(), "  GET  /v1/results/{id}    result by id\n")
+		fmt.Fprintf(fs.Output(), "  GET  /v1/hostinfo        host information\n\n")
+		fs.PrintDefaults()
+	}
+
+	listen := fs.String("listen", defaultListenAddr, "address to listen on")
+	keep := fs.Int("keep", httpapi.DefaultKeepResults, "number of results kept for /v1/results/{id}")
+	allowInline := fs.Bool("allow-inline-config", false, "accept a config in the POST /v1/run body. Any client that can reach -listen may then probe arbitrary targets")
+	run := registerRunFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errFlagsReported
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	s := &serveConf{rsvpckConf: NewRsvpckConf()}
+	if err := run.apply(&s.rsvpckConf); err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(*listen); err != nil {
+		return nil, fmt.Errorf("-listen: %w", err)
+	}
+	s.listen = *listen
+	if *keep < 1 {
+		return nil, fmt.Errorf("-keep must be at least 1")
+	}
+	s.keep = *keep
+	s.allowInline = *allowInline
+	return s, nil
+}
+
+// runServe serves the API until SIGINT/SIGTERM.
+func runServe(args []string) int {
+	serveConf, err := parseServeFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitDirect
+		}
+		if !errors.Is(err, errFlagsReported) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return exitConfigInvalid
+	}
+
+	testConfig, configSource, err := config.Resolve(serveConf.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+	if _, err := newExecutor(&serveConf.rsvpckConf, testConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+
+	runChecks := func(ctx context.Context, cfg domain.NetTestConfig) (domain.ConnectivityResult, error) {
+		executor, err := newExecutor(&serveConf.rsvpckConf, cfg)
+		if err != nil {
+			return domain.ConnectivityResult{}, err
+		}
+		return executor.Run(ctx, cfg), nil
+	}
+	api := httpapi.NewServer(runChecks, hostinfo.GetCRMInfo, testConfig,
+		httpapi.WithConfigSource(configSource.String()),
+		httpapi.WithDeadline(serveConf.deadline),
+		httpapi.WithKeepResults(serveConf.keep),
+		httpapi.WithInlineConfig(serveConf.allowInline))
+
+	srv := &http.Server{
+		Addr:              serveConf.listen,
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("%s %s serving on http://%s (config: %s)", applicationName, version, serveConf.listen, configSource)
+
+	select {
+	case err := <-errc:
+		log.Printf("serve: %v", err)
+		return exitInternalError
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+		return exitInternalError
+	}
+	return exitDirect
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/adapters/render/text"
+	"github.com/azargarov/rsvpck/internal/app"
+	"github.com/azargarov/rsvpck/internal/config"
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+const (
+	cmdWatch             = "watch"
+	defaultWatchInterval = 30 * time.Second
+)
+
+type watchConf struct {
+	rsvpckConf
+	interval time.Duration
+	history  int
+}
+
+func parseWatchFlags(args []string) (*watchConf, error) {
+	fs := flag.NewFlagSet(applicationName+" "+cmdWatch, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of rsvpck watch:\n")
+		fmt.Fprintf(fs.Output(), "Reruns the checks every interval and prints mode changes and endpoints\n")
+		fmt.Fprintf(fs.Output(), "flipping between pass and fail. Ctrl+C prints the uptime summary.\n\n")
+		fs.PrintDefaults()
+	}
+
+	interval := fs.Duration("interval", defaultWatchInterval, "time between the start of two runs")
+	history := fs.Int("history", app.DefaultHistorySize, "number of results kept in memory")
+	run := registerRunFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errFlagsReported
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	w := &watchConf{rsvpckConf: NewRsvpckConf()}
+	if err := run.apply(&w.rsvpckConf); err != nil {
+		return nil, err
+	}
+	if *interval <= 0 {
+		return nil, fmt.Errorf("-interval must be greater than 0")
+	}
+	w.interval = *interval
+	if *history < 1 {
+		return nil, fmt.Errorf("-history must be at least 1")
+	}
+	w.history = *history
+	return w, nil
+}
+
+// runWatch reruns the checks until SIGINT/SIGTERM and prints transitions as
+// they happen, followed by an uptime summary per endpoint.
+func runWatch(args []string) int {
+	watchConf, err := parseWatchFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitDirect
+		}
+		if !errors.Is(err, errFlagsReported) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return exitConfigInvalid
+	}
+
+	testConfig, configSource, err := config.Resolve(watchConf.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+	executor, err := newExecutor(&watchConf.rsvpckConf, testConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return exitConfigInvalid
+	}
+
+	renderConf := text.NewRenderConfig(text.WithForceASCII(watchConf.forceASCII))
+	printHeader(configSource)
+	fmt.Printf("Watching every %s, press Ctrl+C to stop\n\n", watchConf.interval)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	started := time.Now()
+	watcher := app.NewWatcher(executor, testConfig, watchConf.interval, watchConf.deadline, app.NewHistory(watchConf.history))
+	first := true
+	watcher.Run(ctx, func(result domain.ConnectivityResult, transitions []domain.Transition) {
+		if first {
+			text.PrintWatchStart(os.Stdout, result, renderConf)
+			first = false
+			return
+		}
+		text.PrintTransitions(os.Stdout, transitions, renderConf)
+	})
+
+	history := watcher.History()
+	runs, connected := history.Runs()
+	text.RenderUptime(os.Stdout, time.Since(started), runs, connected, history.Uptime(), renderConf)
+	return exitDirect
+}
@@ -43,9 +43,9 @@ func (c Checker) doRequest(ctx context.Context, ep domain.Endpoint, proxyURL *ur
 		transport = t
 	}
 
+	// the request is bounded by the deadline of ctx
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   10 * time.Second, // overall request timeout
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse // don't follow redirects
 		},
@@ -94,9 +94,11 @@ func (c Checker) doRequest(ctx context.Context, ep domain.Endpoint, proxyURL *ur
 		)
 	}
 	var errorCode domain.ErrorCode
+	status := domain.StatusHTTPError
 	switch {
 	case resp.StatusCode == 407:
 		errorCode = domain.ErrorCodeProxyAuthRequired
+		status = domain.StatusProxyAuth
 	case resp.StatusCode >= 500:
 		errorCode = domain.ErrorCodeHTTPBadStatus
 	case resp.StatusCode >= 400:
@@ -110,7 +112,7 @@ func (c Checker) doRequest(ctx context.Context, ep domain.Endpoint, proxyURL *ur
 	)
 	return domain.NewFailedProbe(
 		ep,
-		domain.StatusHTTPError,
+		status,
 		detailedErr,
 	)
 }
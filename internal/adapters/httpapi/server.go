@@ -0,0 +1,250 @@
+// Package httpapi exposes connectivity runs over a local HTTP API so other
+// programs can query connectivity without scraping stdout.
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	jsonrender "github.com/azargarov/rsvpck/internal/adapters/render/json"
+	"github.com/azargarov/rsvpck/internal/config"
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+const (
+	// DefaultKeepResults is the number of results kept for GET /v1/results/{id}.
+	DefaultKeepResults = 50
+	maxConfigBytes     = 1 << 20
+)
+
+// RunFunc executes the checks of cfg. It returns an error when cfg cannot be
+// run, e.g. because of an unknown policy.
+type RunFunc func(ctx context.Context, cfg domain.NetTestConfig) (domain.ConnectivityResult, error)
+
+// HostInfoFunc gathers information about the host the server runs on.
+type HostInfoFunc func(ctx context.Context) domain.HostInfo
+
+// Result is the JSON result model with the id it can be fetched by.
+type Result struct {
+	ID string `json:"id"`
+	jsonrender.Report
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type storedResult struct {
+	id     string
+	result domain.ConnectivityResult
+	source string
+}
+
+type Server struct {
+	run          RunFunc
+	hostInfo     HostInfoFunc
+	config       domain.NetTestConfig
+	configSource string
+	deadline     time.Duration
+	keep         int
+	allowInline  bool
+
+	runMu   sync.Mutex // one run at a time, probes of parallel runs would disturb each other
+	mu      sync.Mutex
+	nextID  int
+	results []storedResult
+}
+
+type Option func(*Server)
+
+// WithConfigSource names the default config in the reports.
+func WithConfigSource(s string) Option { return func(srv *Server) { srv.configSource = s } }
+
+// WithDeadline bounds every run, 0 leaves runs bounded by the request only.
+func WithDeadline(d time.Duration) Option { return func(srv *Server) { srv.deadline = d } }
+
+// WithInlineConfig lets run requests bring their own config. Such a config
+// may probe any target, so only enable it when every client that can reach
+// the server is trusted.
+func WithInlineConfig(v bool) Option { return func(srv *Server) { srv.allowInline = v } }
+
+// WithKeepResults sets how many results stay available by id.
+func WithKeepResults(n int) Option {
+	return func(srv *Server) {
+		if n > 0 {
+			srv.keep = n
+		}
+	}
+}
+
+// NewServer creates a server running cfg unless a run request brings its own config.
+func NewServer(run RunFunc, hostInfo HostInfoFunc, cfg domain.NetTestConfig, opts ...Option) *Server {
+	s := &Server{run: run, hostInfo: hostInfo, config: cfg, keep: DefaultKeepResults}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler returns the API routes:
+//
+//	POST /v1/run              run the checks, with WithInlineConfig the body may hold a YAML or JSON config
+//	GET  /v1/results/latest   most recent result
+//	GET  /v1/results/{id}     result by id
+//	GET  /v1/hostinfo         host information
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/run", s.handleRun)
+	mux.HandleFunc("GET /v1/results/latest", s.handleLatest)
+	mux.HandleFunc("GET /v1/results/{id}", s.handleResult)
+	mux.HandleFunc("GET /v1/hostinfo", s.handleHostInfo)
+	return mux
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	cfg, source, err := s.requestConfig(w, r)
+	if errors.Is(err, errInlineConfigDisabled) {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !s.runMu.TryLock() {
+		w.Header().Set("Retry-After", "5")
+		writeError(w, http.StatusConflict, errors.New("a run is already in progress"))
+		return
+	}
+	defer s.runMu.Unlock()
+
+	ctx := r.Context()
+	if s.deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.deadline)
+		defer cancel()
+	}
+	result, err := s.run(ctx, cfg)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stored := s.store(result, source)
+	w.Header().Set("Location", "/v1/results/"+stored.id)
+	writeJSON(w, http.StatusCreated, stored.toJSON())
+}
+
+var errInlineConfigDisabled = errors.New("inline configs are disabled, post an empty body to run the server config")
+
+// requestConfig returns the config posted with the request, or the server
+// config when the body is empty.
+func (s *Server) requestConfig(w http.ResponseWriter, r *http.Request) (domain.NetTestConfig, string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxConfigBytes))
+	if err != nil {
+		return domain.NetTestConfig{}, "", fmt.Errorf("read config: %w", err)
+	}
+	if len(body) == 0 {
+		return s.config, s.configSource, nil
+	}
+	if !s.allowInline {
+		return domain.NetTestConfig{}, "", errInlineConfigDisabled
+	}
+	cfg, err := config.Parse(body)
+	if err != nil {
+		return domain.NetTestConfig{}, "", err
+	}
+	return cfg, "request", nil
+}
+
+func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var (
+		latest storedResult
+		ok     bool
+	)
+	if n := len(s.results); n > 0 {
+		latest, ok = s.results[n-1], true
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("no run yet, POST /v1/run first"))
+		return
+	}
+	writeJSON(w, http.StatusOK, latest.toJSON())
+}
+
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	var (
+		found storedResult
+		ok    bool
+	)
+	for _, stored := range s.results {
+		if stored.id == id {
+			found, ok = stored, true
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("result %q not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, found.toJSON())
+}
+
+func (s *Server) handleHostInfo(w http.ResponseWriter, r *http.Request) {
+	h := s.hostInfo(r.Context())
+
+	s.mu.Lock()
+	if n := len(s.results); n > 0 {
+		latest := s.results[n-1].result
+		h.SetTLS(latest.CertificateChain(), latest.TLSSession())
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, jsonrender.NewHost(h))
+}
+
+func (s *Server) store(result domain.ConnectivityResult, source string) storedResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	stored := storedResult{id: strconv.Itoa(s.nextID), result: result, source: source}
+	s.results = append(s.results, stored)
+	if len(s.results) > s.keep {
+		s.results = s.results[len(s.results)-s.keep:]
+	}
+	return stored
+}
+
+func (r storedResult) toJSON() Result {
+	rep := jsonrender.NewReport(r.result, nil)
+	rep.ConfigSource = r.source
+	return Result{ID: r.id, Report: rep}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,150 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// fakeRun passes every endpoint of the config and records the configs it ran.
+type fakeRun struct {
+	mu   sync.Mutex
+	cfgs []domain.NetTestConfig
+}
+
+func (f *fakeRun) run(ctx context.Context, cfg domain.NetTestConfig) (domain.ConnectivityResult, error) {
+	f.mu.Lock()
+	f.cfgs = append(f.cfgs, cfg)
+	f.mu.Unlock()
+
+	var probes []domain.Probe
+	for _, ep := range cfg.DirectEndpoints {
+		probes = append(probes, domain.NewSuccessfulProbe(ep, 1))
+	}
+	return domain.NewConnectivityResult(domain.ModeDirect, probes), nil
+}
+
+func (f *fakeRun) runs() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.cfgs)
+}
+
+func newTestServer(t *testing.T, opts ...Option) (*httptest.Server, *fakeRun) {
+	t.Helper()
+	f := &fakeRun{}
+	cfg := domain.NetTestConfig{DirectEndpoints: []domain.Endpoint{
+		domain.MustNewTCPEndpoint("default.example:443", domain.EndpointTypePublic, ""),
+	}}
+	hostInfo := func(context.Context) domain.HostInfo { return domain.HostInfo{Hostname: "host-1"} }
+	srv := httptest.NewServer(NewServer(f.run, hostInfo, cfg, opts...).Handler())
+	t.Cleanup(srv.Close)
+	return srv, f
+}
+
+func do(t *testing.T, method, url, body string) (int, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("%s %s: response is not JSON: %s", method, url, raw)
+	}
+	return resp.StatusCode, out
+}
+
+func firstTarget(t *testing.T, res map[string]any) string {
+	t.Helper()
+	probes, _ := res["probes"].([]any)
+	if len(probes) == 0 {
+		t.Fatalf("result has no probes: %v", res)
+	}
+	ep := probes[0].(map[string]any)["endpoint"].(map[string]any)
+	return ep["target"].(string)
+}
+
+func TestRunAndResults(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	if code, res := do(t, "GET", srv.URL+"/v1/results/latest", ""); code != http.StatusNotFound || res["error"] == "" {
+		t.Errorf("latest before the first run = %d %v, want 404 with an error", code, res)
+	}
+
+	code, run := do(t, "POST", srv.URL+"/v1/run", "")
+	if code != http.StatusCreated {
+		t.Fatalf("POST /v1/run = %d %v, want 201", code, run)
+	}
+	if run["id"] != "1" || run["schemaVersion"] == nil || firstTarget(t, run) != "default.example:443" {
+		t.Errorf("run result = %v, want id 1 of the server config", run)
+	}
+
+	code, latest := do(t, "GET", srv.URL+"/v1/results/latest", "")
+	if code != http.StatusOK || latest["id"] != "1" {
+		t.Errorf("latest = %d %v, want 200 with id 1", code, latest)
+	}
+	if code, byID := do(t, "GET", srv.URL+"/v1/results/1", ""); code != http.StatusOK || byID["id"] != "1" {
+		t.Errorf("result 1 = %d %v, want 200", code, byID)
+	}
+	if code, _ := do(t, "GET", srv.URL+"/v1/results/2", ""); code != http.StatusNotFound {
+		t.Errorf("unknown result = %d, want 404", code)
+	}
+	if code, host := do(t, "GET", srv.URL+"/v1/hostinfo", ""); code != http.StatusOK || host["hostname"] != "host-1" {
+		t.Errorf("hostinfo = %d %v, want 200 with the hostname", code, host)
+	}
+}
+
+func TestInlineConfig(t *testing.T) {
+	inline := "directEndpoints:\n  - {kind: tcp, type: public, target: \"inline.example:443\"}\n"
+
+	t.Run("disabled by default", func(t *testing.T) {
+		srv, f := newTestServer(t)
+		if code, res := do(t, "POST", srv.URL+"/v1/run", inline); code != http.StatusForbidden {
+			t.Errorf("inline config = %d %v, want 403", code, res)
+		}
+		if n := f.runs(); n != 0 {
+			t.Errorf("ran %d configs, want none", n)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		srv, _ := newTestServer(t, WithInlineConfig(true))
+		code, res := do(t, "POST", srv.URL+"/v1/run", inline)
+		if code != http.StatusCreated || firstTarget(t, res) != "inline.example:443" || res["configSource"] != "request" {
+			t.Errorf("inline config = %d %v, want 201 running the posted config", code, res)
+		}
+	})
+
+	t.Run("bad config", func(t *testing.T) {
+		srv, f := newTestServer(t, WithInlineConfig(true))
+		for _, body := range []string{
+			"directEndpoints: [",
+			"directEndpoints:\n  - {kind: tcp, type: public, target: \"no-port\"}\n",
+			"directEndpoints:\n  - {kind: carrier-pigeon, type: public, target: \"x\"}\n",
+		} {
+			if code, res := do(t, "POST", srv.URL+"/v1/run", body); code != http.StatusBadRequest || res["error"] == "" {
+				t.Errorf("config %q = %d %v, want 400 with an error", body, code, res)
+			}
+		}
+		if n := f.runs(); n != 0 {
+			t.Errorf("ran %d configs, want none", n)
+		}
+	})
+}
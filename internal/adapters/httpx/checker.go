@@ -0,0 +1,181 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+type TLSChecker struct{}
+
+var _ domain.TLSChecker = (*TLSChecker)(nil)
+
+// CheckTLSWithContext performs a TLS handshake with ep.Target, directly or via
+// one of ep.TLS.Proxies, and grades the presented chain:
+// fail when the handshake fails, the chain does not verify against the system
+// roots, the leaf is not valid now or an assertion of ep.TLS does not hold,
+// warning when the leaf expires within the threshold or another chain
+// certificate is not valid, pass otherwise.
+func (c TLSChecker) CheckTLSWithContext(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	if ep.TLS.DetectsInterception() {
+		return c.checkAllPaths(ctx, ep)
+	}
+
+	start := time.Now()
+	hs, err := GetCertificatesSmart(ctx, ep.Target, ep.TLS.ServerName, ep.TLS.Proxies, ep.TLS.MinVersion)
+	latencyMs := time.Since(start).Seconds() * 1000
+	return gradeHandshake(ctx, ep, hs, err, latencyMs)
+}
+
+// checkAllPaths fetches the chain over the direct path and every proxy and
+// fails when one of them presents a leaf the endpoint does not expect. The
+// other assertions are checked on the first chain that could be fetched.
+func (c TLSChecker) checkAllPaths(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	start := time.Now()
+	handshakes, errs := GetCertificatesAllPaths(ctx, ep.Target, ep.TLS.ServerName, ep.TLS.Proxies, ep.TLS.MinVersion)
+	latencyMs := time.Since(start).Seconds() * 1000
+
+	vias := append([]string{""}, ep.TLS.Proxies...)
+	paths := make([]domain.TLSPath, len(vias))
+	first, intercepted := -1, -1
+	for i := range vias {
+		paths[i] = domain.TLSPath{Via: vias[i], Certificates: handshakes[i].Certificates}
+		if errs[i] != nil {
+			paths[i].Error = errs[i].Error()
+			continue
+		}
+		if len(paths[i].Certificates) == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if !ep.TLS.IsExpectedLeaf(paths[i].Certificates[0]) {
+			paths[i].Intercepted = true
+			if intercepted < 0 {
+				intercepted = i
+			}
+		}
+	}
+
+	var p domain.Probe
+	switch {
+	case first < 0:
+		p = gradeHandshake(ctx, ep, Handshake{}, firstError(errs), latencyMs)
+	case intercepted >= 0:
+		path := paths[intercepted]
+		p = *domain.NewProbe(ep)
+		p.Certificates = path.Certificates
+		p.TLSSession = &handshakes[intercepted].Session
+		p.MarkFailure(domain.StatusFail, fmt.Errorf("TLS interception (%s): certificate %q issued by %q",
+			path.Name(), path.Certificates[0].Subject, path.Interceptor()))
+		p.LatencyMs = latencyMs
+	default:
+		p = gradeHandshake(ctx, ep, handshakes[first], nil, latencyMs)
+	}
+	p.TLSPaths = paths
+	return p
+}
+
+func firstError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return errors.New("no certificates presented")
+}
+
+// gradeHandshake turns the outcome of a handshake into the probe result.
+func gradeHandshake(ctx context.Context, ep domain.Endpoint, hs Handshake, err error, latencyMs float64) domain.Probe {
+	if err != nil {
+		return domain.NewFailedProbe(ep, mapTLSError(err, ctx.Err()), fmt.Errorf("TLS handshake with %q failed: %w", ep.Target, err))
+	}
+	certs := hs.Certificates
+	if len(certs) == 0 {
+		return domain.NewFailedProbe(ep, domain.StatusFail, fmt.Errorf("%q presented no certificates", ep.Target))
+	}
+
+	p := domain.NewProbe(ep)
+	p.Certificates = certs
+	p.TLSSession = &hs.Session
+
+	leaf := certs[0]
+	if err := checkChain(ep.TLS, hs); err != nil {
+		p.MarkFailure(domain.StatusFail, err)
+		p.LatencyMs = latencyMs
+		return *p
+	}
+	switch {
+	case time.Until(leaf.NotAfter) < ep.TLS.ExpiryThreshold():
+		p.MarkWarning(latencyMs, fmt.Errorf("certificate %q expires %s",
+			leaf.Subject, leaf.NotAfter.Format(time.DateOnly)))
+	default:
+		p.MarkSuccess(latencyMs)
+		for _, cert := range certs[1:] {
+			if !cert.Valid {
+				p.MarkWarning(latencyMs, fmt.Errorf("chain certificate %q is not valid (%s - %s)",
+					cert.Subject, cert.NotBefore.Format(time.DateOnly), cert.NotAfter.Format(time.DateOnly)))
+				break
+			}
+		}
+	}
+	return *p
+}
+
+// checkChain returns why the chain fails the endpoint assertions, nil when
+// it passes them.
+func checkChain(opts domain.TLSOptions, hs Handshake) error {
+	leaf := hs.Certificates[0]
+	switch {
+	case !leaf.Valid:
+		return fmt.Errorf("certificate %q is not valid (%s - %s)",
+			leaf.Subject, leaf.NotBefore.Format(time.DateOnly), leaf.NotAfter.Format(time.DateOnly))
+	case hs.VerifyErr != nil:
+		return fmt.Errorf("chain does not verify against the system roots: %w", hs.VerifyErr)
+	case opts.ExpectSubject != nil && !opts.ExpectSubject.MatchString(leaf.Subject):
+		return fmt.Errorf("certificate subject %q does not match %q", leaf.Subject, opts.ExpectSubject)
+	case opts.ExpectIssuer != nil && !opts.ExpectIssuer.MatchString(leaf.Issuer):
+		return fmt.Errorf("certificate issuer %q does not match %q", leaf.Issuer, opts.ExpectIssuer)
+	}
+	if len(opts.Pins) == 0 {
+		return nil
+	}
+	for _, cert := range hs.Certificates {
+		if slices.Contains(opts.Pins, cert.SPKISHA256) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no certificate in the chain matches the pinned keys, leaf key is sha256/%s", leaf.SPKISHA256)
+}
+
+func mapTLSError(err, contextErr error) domain.Status {
+	if contextErr != nil || errors.Is(err, context.DeadlineExceeded) {
+		return domain.StatusTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.Timeout() {
+		return domain.StatusDNSFailure
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return domain.StatusTimeout
+	}
+
+	errStr := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(errStr, "connection refused"), strings.Contains(errStr, "connection reset"):
+		return domain.StatusConnectionRefused
+	case strings.Contains(errStr, "proxy connect failed") && strings.Contains(errStr, " 407 "):
+		return domain.StatusProxyAuth
+	}
+	return domain.StatusFail
+}
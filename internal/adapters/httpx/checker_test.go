@@ -0,0 +1,387 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate for cn valid from notBefore to notAfter, signed
+// by parent or self-signed when parent is nil.
+func issue(t *testing.T, cn string, notBefore, notAfter time.Time, parent *testCert) testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		DNSNames:     []string{cn},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCert{cert: cert, key: key}
+}
+
+// handshake builds what fetchCertsOverConn returns for chain.
+func handshake(chain ...testCert) Handshake {
+	now := time.Now()
+	var hs Handshake
+	for i, c := range chain {
+		cert := newCertificate(c.cert)
+		cert.ChainIndex = i
+		cert.Valid = !now.Before(c.cert.NotBefore) && !now.After(c.cert.NotAfter)
+		hs.Certificates = append(hs.Certificates, cert)
+	}
+	return hs
+}
+
+func unverified(hs Handshake) Handshake {
+	hs.VerifyErr = errors.New("x509: certificate signed by unknown authority")
+	return hs
+}
+
+func spkiPin(c testCert) string {
+	sum := sha256.Sum256(c.cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func fingerprint(c testCert) string {
+	sum := sha256.Sum256(c.cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCheckChain(t *testing.T) {
+	now := time.Now()
+	ca := issue(t, "Test Root CA", now.Add(-time.Hour), now.Add(365*24*time.Hour), nil)
+	leaf := issue(t, "api.example.com", now.Add(-time.Hour), now.Add(90*24*time.Hour), &ca)
+	expired := issue(t, "api.example.com", now.Add(-48*time.Hour), now.Add(-24*time.Hour), &ca)
+	other := issue(t, "Other CA", now.Add(-time.Hour), now.Add(time.Hour), nil)
+
+	tests := []struct {
+		name    string
+		opts    domain.TLSOptions
+		hs      Handshake
+		wantErr string
+	}{
+		{name: "no assertions", hs: handshake(leaf, ca)},
+		{name: "expired leaf", hs: handshake(expired, ca), wantErr: "is not valid"},
+		{
+			name:    "chain does not verify",
+			hs:      unverified(handshake(leaf, ca)),
+			wantErr: "does not verify against the system roots",
+		},
+		{name: "subject matches", opts: domain.TLSOptions{ExpectSubject: regexp.MustCompile(`CN=api\.example\.com`)}, hs: handshake(leaf, ca)},
+		{name: "subject mismatch", opts: domain.TLSOptions{ExpectSubject: regexp.MustCompile(`CN=www\.`)}, hs: handshake(leaf, ca), wantErr: "certificate subject"},
+		{name: "issuer matches", opts: domain.TLSOptions{ExpectIssuer: regexp.MustCompile("Test Root")}, hs: handshake(leaf, ca)},
+		{name: "issuer mismatch", opts: domain.TLSOptions{ExpectIssuer: regexp.MustCompile("DigiCert")}, hs: handshake(leaf, ca), wantErr: "certificate issuer"},
+		{name: "leaf pin", opts: domain.TLSOptions{Pins: []string{spkiPin(leaf)}}, hs: handshake(leaf, ca)},
+		{name: "ca pin", opts: domain.TLSOptions{Pins: []string{spkiPin(other), spkiPin(ca)}}, hs: handshake(leaf, ca)},
+		{
+			name:    "no pin matches",
+			opts:    domain.TLSOptions{Pins: []string{spkiPin(other)}},
+			hs:      handshake(leaf, ca),
+			wantErr: "sha256/" + spkiPin(leaf),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkChain(tt.opts, tt.hs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkChain = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkChain = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGradeHandshakeExpiry(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	ca := issue(t, "Test Root CA", now.Add(-time.Hour), now.Add(365*day), nil)
+	expiredCA := issue(t, "Old Root CA", now.Add(-48*time.Hour), now.Add(-day), nil)
+	soon := issue(t, "api.example.com", now.Add(-time.Hour), now.Add(5*day), &ca)
+	later := issue(t, "api.example.com", now.Add(-time.Hour), now.Add(60*day), &ca)
+
+	tests := []struct {
+		name       string
+		warning    time.Duration
+		hs         Handshake
+		wantStatus domain.Status
+		wantErr    string
+	}{
+		{name: "default threshold, expires in 5 days", hs: handshake(soon, ca), wantStatus: domain.StatusWarning, wantErr: "expires"},
+		{name: "default threshold, expires in 60 days", hs: handshake(later, ca), wantStatus: domain.StatusPass},
+		{name: "1 day threshold", warning: day, hs: handshake(soon, ca), wantStatus: domain.StatusPass},
+		{name: "90 day threshold", warning: 90 * day, hs: handshake(later, ca), wantStatus: domain.StatusWarning, wantErr: "expires"},
+		{name: "invalid chain certificate", hs: handshake(later, expiredCA), wantStatus: domain.StatusWarning, wantErr: "chain certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep, err := domain.NewTLSEndpoint("api.example.com:443", domain.EndpointTypePublic, "", nil, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ep.TLS.ExpiryWarning = tt.warning
+
+			p := gradeHandshake(context.Background(), ep, tt.hs, nil, 12)
+			if p.Status != tt.wantStatus {
+				t.Errorf("status = %s (%s), want %s", p.Status, p.Error, tt.wantStatus)
+			}
+			if !strings.Contains(p.Error, tt.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", p.Error, tt.wantErr)
+			}
+			if len(p.Certificates) != len(tt.hs.Certificates) {
+				t.Errorf("probe keeps %d certificates, want %d", len(p.Certificates), len(tt.hs.Certificates))
+			}
+		})
+	}
+}
+
+func TestGetCertificatesFromServer(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hs, err := GetCertificatesSmart(ctx, addr, "example.com", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hs.Certificates) == 0 || !strings.Contains(hs.Certificates[0].Issuer, "Acme") {
+		t.Fatalf("certificates = %+v, want the httptest leaf", hs.Certificates)
+	}
+	if hs.VerifyErr == nil {
+		t.Error("the httptest certificate must not verify against the system roots")
+	}
+	if hs.Session.Version != "TLS 1.2" {
+		t.Errorf("session version = %q, want TLS 1.2", hs.Session.Version)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	if err := VerifyChain(hs.Certificates, "example.com", roots); err != nil {
+		t.Errorf("VerifyChain with the server certificate as root = %v", err)
+	}
+	if _, err := GetCertificatesSmart(ctx, addr, "example.com", nil, tls.VersionTLS13); err == nil {
+		t.Error("a TLS 1.2 server must fail a TLS 1.3 minimum version")
+	}
+}
+
+// tlsServer serves chain, leaf first, with the key of the leaf.
+func tlsServer(t *testing.T, chain ...testCert) string {
+	t.Helper()
+	cert := tls.Certificate{PrivateKey: chain[0].key}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.cert.Raw)
+	}
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv.Listener.Addr().String()
+}
+
+// connectProxy answers every CONNECT with 200 and tunnels to upstream,
+// whatever the requested target, like an intercepting proxy does.
+func connectProxy(t *testing.T, upstream string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				br := bufio.NewReader(conn)
+				req, err := http.ReadRequest(br)
+				if err != nil || req.Method != http.MethodConnect {
+					return
+				}
+				up, err := net.Dial("tcp", upstream)
+				if err != nil {
+					return
+				}
+				_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+				go func() {
+					_, _ = io.Copy(up, br)
+					_ = up.Close()
+				}()
+				_, _ = io.Copy(conn, up)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// closedAddr is an address nothing listens on.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr
+}
+
+func TestCheckAllPaths(t *testing.T) {
+	now := time.Now()
+	ca := issue(t, "Test Root CA", now.Add(-time.Hour), now.Add(365*24*time.Hour), nil)
+	leaf := issue(t, "api.example.com", now.Add(-time.Hour), now.Add(90*24*time.Hour), &ca)
+	inspector := issue(t, "Inspecting Firewall CA", now.Add(-time.Hour), now.Add(365*24*time.Hour), nil)
+	forged := issue(t, "api.example.com", now.Add(-time.Hour), now.Add(90*24*time.Hour), &inspector)
+
+	direct := tlsServer(t, leaf, ca)
+	intercepting := connectProxy(t, tlsServer(t, forged, inspector))
+	passThrough := connectProxy(t, direct)
+	down := closedAddr(t)
+
+	tests := []struct {
+		name            string
+		target          string
+		proxies         []string
+		fingerprints    []string
+		wantStatus      domain.Status
+		wantErr         string
+		wantIntercepted []bool
+	}{
+		{
+			name:            "proxy presents a foreign leaf",
+			target:          direct,
+			proxies:         []string{passThrough, intercepting},
+			wantStatus:      domain.StatusFail,
+			wantErr:         `TLS interception (via ` + intercepting + `): certificate "CN=api.example.com" issued by "CN=Inspecting Firewall CA"`,
+			wantIntercepted: []bool{false, false, true},
+		},
+		{
+			name:            "foreign fingerprint",
+			target:          direct,
+			proxies:         []string{passThrough},
+			fingerprints:    []string{fingerprint(forged)},
+			wantStatus:      domain.StatusFail,
+			wantErr:         "TLS interception (direct)",
+			wantIntercepted: []bool{true, true},
+		},
+		{
+			// the expected chain is graded, the test CA is not a system root
+			name:            "every path presents the expected leaf",
+			target:          direct,
+			proxies:         []string{passThrough},
+			fingerprints:    []string{fingerprint(leaf)},
+			wantStatus:      domain.StatusFail,
+			wantErr:         "does not verify against the system roots",
+			wantIntercepted: []bool{false, false},
+		},
+		{
+			name:            "direct path down, proxy intercepts",
+			target:          down,
+			proxies:         []string{intercepting},
+			wantStatus:      domain.StatusFail,
+			wantErr:         "TLS interception (via " + intercepting + ")",
+			wantIntercepted: []bool{false, true},
+		},
+		{
+			name:            "all paths fail",
+			target:          down,
+			proxies:         []string{closedAddr(t)},
+			wantStatus:      domain.StatusConnectionRefused,
+			wantErr:         `TLS handshake with "` + down + `" failed`,
+			wantIntercepted: []bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep, err := domain.NewTLSEndpoint(tt.target, domain.EndpointTypePublic, "api.example.com", tt.proxies, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ep.TLS.ExpectedIssuers = []*regexp.Regexp{regexp.MustCompile("Test Root CA")}
+			ep.TLS.LeafFingerprints = tt.fingerprints
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			p := TLSChecker{}.CheckTLSWithContext(ctx, ep)
+
+			if p.Status != tt.wantStatus || !strings.Contains(p.Error, tt.wantErr) {
+				t.Errorf("probe = %s %q, want %s containing %q", p.Status, p.Error, tt.wantStatus, tt.wantErr)
+			}
+			if len(p.TLSPaths) != len(tt.wantIntercepted) {
+				t.Fatalf("probe has %d paths, want %d", len(p.TLSPaths), len(tt.wantIntercepted))
+			}
+			for i, path := range p.TLSPaths {
+				if path.Intercepted != tt.wantIntercepted[i] {
+					t.Errorf("path %s intercepted = %v, want %v", path.Name(), path.Intercepted, tt.wantIntercepted[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFirstError(t *testing.T) {
+	refused := errors.New("connection refused")
+	if err := firstError([]error{nil, refused, errors.New("proxy dial failed")}); err != refused {
+		t.Errorf("firstError = %v, want the first non-nil error", err)
+	}
+	if err := firstError([]error{nil, nil}); err == nil || err.Error() != "no certificates presented" {
+		t.Errorf("firstError without errors = %v, want no certificates presented", err)
+	}
+}
@@ -3,8 +3,14 @@ package httpx
 import (
 	"bufio"
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
@@ -15,35 +21,68 @@ import (
 	"github.com/azargarov/rsvpck/internal/domain"
 )
 
-const singleProxyTimeout = 2 *time.Second
-
-func GetCertificatesSmart(ctx context.Context, addr, serverName string, vpnProxy []string) ([]domain.TLSCertificate, error) {
-
-	totalTimeout := singleProxyTimeout * time.Duration(len(vpnProxy) + 1)
-    parentCtx, parentCancel := context.WithTimeout(ctx, totalTimeout)
-    defer parentCancel()
-
-    directCtx, cancel := context.WithTimeout(parentCtx, singleProxyTimeout)
-    certs, err := GetCertificatesViaProxy(directCtx, addr, serverName, "")
-    cancel()
-    //err = errors.New("debug: force proxy fallback")
-    if err != nil {
-        for _, proxy := range vpnProxy {
-            attemptCtx, cancel := context.WithTimeout(parentCtx, singleProxyTimeout)
-            certs, proxyErr := GetCertificatesViaProxy(attemptCtx, addr, serverName, proxy)
-            cancel()
-            if proxyErr == nil {
-                return certs, nil
-            }
-            //fmt.Printf("[DEBUG] proxy %s failed: %v\n", proxy, proxyErr)
-        }
-    }
-    return certs, err  // TODO: add custom error 
+// Handshake is what a TLS handshake revealed about the server.
+type Handshake struct {
+	Certificates []domain.TLSCertificate
+	Session      domain.TLSSession
+	// VerifyErr is set when the chain does not verify against the system
+	// roots for the server name, the certificates are still returned.
+	VerifyErr error
 }
 
-func GetCertificatesViaProxy(ctx context.Context, targetAddr, serverName, proxyAddr string) ([]domain.TLSCertificate, error) {
+// GetCertificatesSmart fetches the chain of addr directly and falls back to
+// the vpnProxy addresses in order. The deadline of ctx is split evenly over
+// the paths that are left, so a stuck direct handshake still leaves time for
+// the proxies. A chain that does not verify is not a reason to try another
+// path, it is returned with VerifyErr set.
+func GetCertificatesSmart(ctx context.Context, addr, serverName string, vpnProxy []string, minVersion uint16) (Handshake, error) {
+	paths := append([]string{""}, vpnProxy...)
+
+	var firstErr error
+	for i, proxy := range paths {
+		attemptCtx, cancel := pathContext(ctx, len(paths)-i)
+		hs, err := GetCertificatesViaProxy(attemptCtx, addr, serverName, proxy, minVersion)
+		cancel()
+		if err == nil {
+			return hs, nil
+		}
+		if firstErr == nil {
+			firstErr = err // the direct error describes the target best
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return Handshake{}, firstErr
+}
+
+// GetCertificatesAllPaths fetches the chain of addr directly and via every
+// vpnProxy address, for comparing what each path presents. The deadline of
+// ctx is split over the paths like in GetCertificatesSmart.
+func GetCertificatesAllPaths(ctx context.Context, addr, serverName string, vpnProxy []string, minVersion uint16) ([]Handshake, []error) {
+	paths := append([]string{""}, vpnProxy...)
+	handshakes := make([]Handshake, len(paths))
+	errs := make([]error, len(paths))
+	for i, proxy := range paths {
+		attemptCtx, cancel := pathContext(ctx, len(paths)-i)
+		handshakes[i], errs[i] = GetCertificatesViaProxy(attemptCtx, addr, serverName, proxy, minVersion)
+		cancel()
+	}
+	return handshakes, errs
+}
+
+// pathContext gives one of the remaining paths its share of the ctx deadline.
+func pathContext(ctx context.Context, remaining int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || remaining <= 1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(remaining))
+}
+
+func GetCertificatesViaProxy(ctx context.Context, targetAddr, serverName, proxyAddr string, minVersion uint16) (Handshake, error) {
 	if targetAddr == "" {
-		return nil, errors.New("targetAddr is required (host:port)")
+		return Handshake{}, errors.New("targetAddr is required (host:port)")
 	}
 	if serverName == "" {
 		serverName = hostPart(targetAddr)
@@ -57,25 +96,20 @@ func GetCertificatesViaProxy(ctx context.Context, targetAddr, serverName, proxyA
 	if proxyAddr == "" {
 		conn, err = dialContext(ctx, "tcp", targetAddr)
 		if err != nil {
-			return nil, err
+			return Handshake{}, err
 		}
-		return fetchCertsOverConn(ctx, conn, serverName)
+		return fetchCertsOverConn(ctx, conn, serverName, minVersion)
 	}
 
 	conn, err = dialThroughHTTPProxy(ctx, proxyAddr, targetAddr)
 	if err != nil {
-		return nil, err
+		return Handshake{}, err
 	}
-	return fetchCertsOverConn(ctx, conn, serverName)
+	return fetchCertsOverConn(ctx, conn, serverName, minVersion)
 }
 
 func dialContext(ctx context.Context, network, address string) (net.Conn, error) {
-	d := &net.Dialer{}
-	if deadline, ok := ctx.Deadline(); ok {
-		d.Timeout = time.Until(deadline)
-	} else {
-		d.Timeout = 2 * time.Second
-	}
+	var d net.Dialer
 	return d.DialContext(ctx, network, address)
 }
 
@@ -158,30 +192,111 @@ func dialThroughHTTPProxy(ctx context.Context, proxyAddr, targetAddr string) (ne
 	return conn, nil
 }
 
-func fetchCertsOverConn(ctx context.Context, rawConn net.Conn, serverName string) ([]domain.TLSCertificate, error) {
-	cfg := &tls.Config{ServerName: serverName}
+// fetchCertsOverConn verifies the chain itself instead of letting the
+// handshake fail, so an untrusted chain, e.g. from an intercepting proxy, can
+// still be reported.
+func fetchCertsOverConn(ctx context.Context, rawConn net.Conn, serverName string, minVersion uint16) (Handshake, error) {
+	cfg := &tls.Config{
+		ServerName:         serverName,
+		MinVersion:         minVersion,
+		NextProtos:         []string{"h2", "http/1.1"},
+		InsecureSkipVerify: true,
+	}
 	tlsConn := tls.Client(rawConn, cfg)
 
 	defer func() { _ = tlsConn.Close() }()
 
 	if err := tlsConn.HandshakeContext(ctx); err != nil {
-		return nil, err
+		return Handshake{}, err
 	}
 
 	state := tlsConn.ConnectionState()
 	now := time.Now()
 
 	out := make([]domain.TLSCertificate, 0, len(state.PeerCertificates))
-	for _, cert := range state.PeerCertificates {
-		out = append(out, domain.TLSCertificate{
-			Subject:   cert.Subject.String(),
-			Issuer:    cert.Issuer.String(),
-			NotBefore: cert.NotBefore,
-			NotAfter:  cert.NotAfter,
-			Valid:     !now.Before(cert.NotBefore) && !now.After(cert.NotAfter),
-		})
-	}
-	return out, nil
+	for i, cert := range state.PeerCertificates {
+		c := newCertificate(cert)
+		c.ChainIndex = i
+		c.Valid = !now.Before(cert.NotBefore) && !now.After(cert.NotAfter)
+		out = append(out, c)
+	}
+	return Handshake{
+		Certificates: out,
+		Session: domain.TLSSession{
+			Version:     tls.VersionName(state.Version),
+			CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+			ALPN:        state.NegotiatedProtocol,
+		},
+		VerifyErr: verifyChain(state.PeerCertificates, serverName, nil),
+	}, nil
+}
+
+func newCertificate(cert *x509.Certificate) domain.TLSCertificate {
+	spki := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	fingerprint := sha256.Sum256(cert.Raw)
+	c := domain.TLSCertificate{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		DNSNames:           cert.DNSNames,
+		SerialNumber:       fmt.Sprintf("%X", cert.SerialNumber),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		IsCA:               cert.IsCA,
+		PublicKeyAlgorithm: cert.PublicKeyAlgorithm.String(),
+		PublicKeyBits:      publicKeyBits(cert.PublicKey),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		FingerprintSHA256:  hex.EncodeToString(fingerprint[:]),
+		SPKISHA256:         base64.StdEncoding.EncodeToString(spki[:]),
+		Raw:                cert.Raw,
+	}
+	for _, ip := range cert.IPAddresses {
+		c.IPAddresses = append(c.IPAddresses, ip.String())
+	}
+	return c
+}
+
+func publicKeyBits(pub any) int {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return ed25519.PublicKeySize * 8
+	}
+	return 0
+}
+
+// VerifyChain checks a fetched chain against roots for serverName, the
+// system roots when roots is nil.
+func VerifyChain(certs []domain.TLSCertificate, serverName string, roots *x509.CertPool) error {
+	chain := make([]*x509.Certificate, 0, len(certs))
+	for _, c := range certs {
+		cert, err := x509.ParseCertificate(c.Raw)
+		if err != nil {
+			return fmt.Errorf("certificate %d: %w", c.ChainIndex, err)
+		}
+		chain = append(chain, cert)
+	}
+	return verifyChain(chain, serverName, roots)
+}
+
+// verifyChain checks the chain against roots the way the default handshake
+// would.
+func verifyChain(chain []*x509.Certificate, serverName string, roots *x509.CertPool) error {
+	if len(chain) == 0 {
+		return errors.New("no certificates presented")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
 }
 
 func parseProxyURL(s string) (*url.URL, error) {
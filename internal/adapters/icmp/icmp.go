@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -13,14 +14,101 @@ import (
 	"github.com/azargarov/rsvpck/internal/domain"
 )
 
-type Checker struct{}
+type Mode int
+
+const (
+	ModeAuto   Mode = iota // native sockets, ping binary when no socket can be opened
+	ModeNative             // native sockets only
+	ModeExec               // OS ping binary only
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeNative:
+		return "native"
+	case ModeExec:
+		return "exec"
+	default:
+		return "auto"
+	}
+}
+
+func ParseMode(s string) (Mode, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "auto":
+		return ModeAuto, true
+	case "native":
+		return ModeNative, true
+	case "exec":
+		return ModeExec, true
+	}
+	return ModeAuto, false
+}
+
+type Checker struct {
+	Mode     Mode
+	Count    int           // echo requests per probe, default 1
+	Interval time.Duration // between echo requests, default 200ms
+}
 
 var _ domain.ICMPChecker = (*Checker)(nil)
 
 func (c *Checker) CheckPingWithContext(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	if c.Mode == ModeExec {
+		return c.checkExec(ctx, ep)
+	}
+
+	probe, err := c.checkNative(ctx, ep)
+	if errors.Is(err, errNoSocket) && c.Mode == ModeAuto {
+		return c.checkExec(ctx, ep)
+	}
+	return probe
+}
+
+// checkNative returns errNoSocket together with a failed probe when ICMP
+// sockets cannot be opened, so the caller may fall back to the ping binary.
+func (c *Checker) checkNative(ctx context.Context, ep domain.Endpoint) (domain.Probe, error) {
+	count := max(c.Count, 1)
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
 
+	stats, err := pingNative(ctx, ep.Target, count, interval)
+	if err != nil {
+		status := domain.StatusInvalidCommand
+		if !errors.Is(err, errNoSocket) {
+			status = mapPingError(err, ctx.Err(), "")
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) {
+				status = domain.StatusDNSFailure
+			}
+		}
+		detailedErr := domain.Errorf(
+			domain.ErrorCodeICMPFailed,
+			"Ping failed %q: %w", ep.Target, err,
+		)
+		return domain.NewFailedProbe(ep, status, detailedErr), err
+	}
+
+	if stats.Received == 0 {
+		detailedErr := domain.Errorf(
+			domain.ErrorCodeICMPFailed,
+			"Ping failed %q: no echo reply, %d sent", ep.Target, stats.Sent,
+		)
+		p := domain.NewFailedProbe(ep, domain.StatusTimeout, detailedErr)
+		p.Echo = stats
+		return p, nil
+	}
+
+	p := domain.NewSuccessfulProbe(ep, stats.AvgRTTMs())
+	p.Echo = stats
+	return p, nil
+}
+
+func (c *Checker) checkExec(ctx context.Context, ep domain.Endpoint) domain.Probe {
 	start := time.Now()
-	ok, output, err := pingHostCmd(ctx, ep.Target, 1)
+	ok, output, err := pingHostCmd(ctx, ep.Target, max(c.Count, 1))
 	latencyMs := time.Since(start).Seconds() * 1000
 
 	if err != nil || !ok {
@@ -69,6 +157,9 @@ func pingHostCmd(ctx context.Context, host string, attempts int) (bool, string,
 	}
 
 	//  "ping: command not found"...
+	if errors.Is(err, exec.ErrNotFound) {
+		return false, output, domain.Errorf(domain.ErrorCodeExecFailed, "ping command not available: %w", err)
+	}
 	if err != nil {
 	    outputLower := strings.ToLower(output)
     	if containsAny(outputLower, "invalid", "unrecognized", "illegal", "command not found") {
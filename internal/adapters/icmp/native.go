@@ -0,0 +1,243 @@
+package icmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+	xicmp "golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+	replyTimeout     = 2 * time.Second
+	defaultInterval  = 200 * time.Millisecond
+)
+
+// errNoSocket means neither a datagram nor a raw ICMP socket could be opened,
+// typically missing privileges. The auto mode falls back to the ping binary.
+var errNoSocket = errors.New("no ICMP socket available")
+
+var errMalformed = errors.New("malformed ICMP message")
+
+type echoConn struct {
+	conn  *xicmp.PacketConn
+	ipv6  bool
+	dgram bool // unprivileged datagram socket, kernel assigns the echo ID
+}
+
+// listen opens an unprivileged datagram ICMP socket and falls back to a raw
+// socket. Datagram sockets are not available on Windows.
+func listen(ipv6 bool) (*echoConn, error) {
+	dgramNet, rawNet, addr := "udp4", "ip4:icmp", "0.0.0.0"
+	if ipv6 {
+		dgramNet, rawNet, addr = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	var errs []error
+	if runtime.GOOS != "windows" {
+		c, err := xicmp.ListenPacket(dgramNet, addr)
+		if err == nil {
+			return newEchoConn(c, ipv6, true), nil
+		}
+		errs = append(errs, err)
+	}
+	c, err := xicmp.ListenPacket(rawNet, addr)
+	if err == nil {
+		return newEchoConn(c, ipv6, false), nil
+	}
+	errs = append(errs, err)
+	return nil, fmt.Errorf("%w: %w", errNoSocket, errors.Join(errs...))
+}
+
+func newEchoConn(c *xicmp.PacketConn, ipv6Conn, dgram bool) *echoConn {
+	// TTL is best effort, not every platform delivers control messages
+	if ipv6Conn {
+		if p := c.IPv6PacketConn(); p != nil {
+			_ = p.SetControlMessage(ipv6.FlagHopLimit, true)
+		}
+	} else if p := c.IPv4PacketConn(); p != nil {
+		_ = p.SetControlMessage(ipv4.FlagTTL, true)
+	}
+	return &echoConn{conn: c, ipv6: ipv6Conn, dgram: dgram}
+}
+
+func (e *echoConn) Close() error { return e.conn.Close() }
+
+func (e *echoConn) dst(ip net.IP) net.Addr {
+	if e.dgram {
+		return &net.UDPAddr{IP: ip}
+	}
+	return &net.IPAddr{IP: ip}
+}
+
+// read returns the next ICMP message with the sender and the TTL/hop limit,
+// ttl is 0 when unknown.
+func (e *echoConn) read(b []byte) (*xicmp.Message, net.Addr, int, error) {
+	var (
+		n    int
+		peer net.Addr
+		ttl  int
+		err  error
+	)
+	switch {
+	case e.ipv6 && e.conn.IPv6PacketConn() != nil:
+		var cm *ipv6.ControlMessage
+		n, cm, peer, err = e.conn.IPv6PacketConn().ReadFrom(b)
+		if cm != nil {
+			ttl = cm.HopLimit
+		}
+	case !e.ipv6 && e.conn.IPv4PacketConn() != nil:
+		var cm *ipv4.ControlMessage
+		n, cm, peer, err = e.conn.IPv4PacketConn().ReadFrom(b)
+		if cm != nil {
+			ttl = cm.TTL
+		}
+	default:
+		n, peer, err = e.conn.ReadFrom(b)
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	proto := protocolICMP
+	if e.ipv6 {
+		proto = protocolIPv6ICMP
+	}
+	msg, err := xicmp.ParseMessage(proto, b[:n])
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("%w: %w", errMalformed, err)
+	}
+	return msg, peer, ttl, nil
+}
+
+// pingNative sends count echo requests to host and collects the replies.
+// It only returns an error when the host cannot be resolved or no socket can
+// be opened, lost packets are reported in the stats.
+func pingNative(ctx context.Context, host string, count int, interval time.Duration) (*domain.EchoStats, error) {
+	ip, err := resolveIP(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	isV6 := ip.To4() == nil
+
+	conn, err := listen(isV6)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var echoType xicmp.Type = ipv4.ICMPTypeEcho
+	if isV6 {
+		echoType = ipv6.ICMPTypeEchoRequest
+	}
+	id := os.Getpid() & 0xffff
+
+	stats := &domain.EchoStats{}
+	buf := make([]byte, 1500)
+	for seq := 1; seq <= count; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+				return stats, nil
+			case <-time.After(interval):
+			}
+		}
+
+		msg := xicmp.Message{
+			Type: echoType,
+			Body: &xicmp.Echo{ID: id, Seq: seq, Data: []byte("rsvpck")},
+		}
+		wb, err := msg.Marshal(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		stats.Sent++
+		if _, err := conn.conn.WriteTo(wb, conn.dst(ip)); err != nil {
+			continue
+		}
+
+		deadline := start.Add(replyTimeout)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		_ = conn.conn.SetReadDeadline(deadline)
+
+		if reply, ok := conn.awaitReply(buf, ip, id, seq, start); ok {
+			stats.Received++
+			stats.Replies = append(stats.Replies, reply)
+		}
+	}
+	return stats, nil
+}
+
+// awaitReply reads until the echo reply for seq arrives or the read deadline
+// passes. Datagram sockets rewrite the echo ID, so it is only checked on raw
+// sockets.
+func (e *echoConn) awaitReply(buf []byte, ip net.IP, id, seq int, start time.Time) (domain.EchoReply, bool) {
+	for {
+		msg, peer, ttl, err := e.read(buf)
+		if errors.Is(err, errMalformed) {
+			continue
+		}
+		if err != nil {
+			// read deadline passed or the socket failed, either way the packet is lost
+			return domain.EchoReply{}, false
+		}
+		if msg.Type != ipv4.ICMPTypeEchoReply && msg.Type != ipv6.ICMPTypeEchoReply {
+			continue
+		}
+		echo, ok := msg.Body.(*xicmp.Echo)
+		if !ok || echo.Seq != seq || (!e.dgram && echo.ID != id) {
+			continue
+		}
+		if !peerIP(peer).Equal(ip) {
+			continue
+		}
+		return domain.EchoReply{
+			Seq:   seq,
+			RTTMs: time.Since(start).Seconds() * 1000,
+			TTL:   ttl,
+		}, true
+	}
+}
+
+func peerIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
+
+// resolveIP prefers IPv4 addresses, like the ping binary does by default.
+func resolveIP(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		if a.IP.To4() != nil {
+			return a.IP, nil
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+	}
+	return addrs[0].IP, nil
+}
@@ -15,7 +15,8 @@ var _ domain.TCPChecker = (*TCPDialer)(nil)
 func (d *TCPDialer) CheckWithContext(ctx context.Context, ep domain.Endpoint) domain.Probe {
 	addr := ep.Target
 	start := time.Now()
-	conn, err := net.DialTimeout("tcp", addr, 3*time.Second)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	latencyMs := time.Since(start).Seconds() * 1000
 
 	if err != nil {
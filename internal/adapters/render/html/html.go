@@ -0,0 +1,219 @@
+// Package html writes a self-contained HTML report, styles included and
+// no external assets, so it can be mailed and opened offline.
+package html
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+var tmpl = template.Must(template.New("report").Parse(reportTemplate))
+
+type Renderer struct {
+	host         *domain.HostInfo
+	configSource string
+	generator    string
+}
+
+type Option func(*Renderer)
+
+func WithHostInfo(h domain.HostInfo) Option { return func(r *Renderer) { r.host = &h } }
+func WithConfigSource(s string) Option      { return func(r *Renderer) { r.configSource = s } }
+
+// WithGenerator names the program and version in the report footer.
+func WithGenerator(s string) Option { return func(r *Renderer) { r.generator = s } }
+
+var _ domain.Renderer = (*Renderer)(nil)
+
+func NewRenderer(opts ...Option) *Renderer {
+	r := &Renderer{generator: "rsvpck"}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Renderer) Render(w io.Writer, result domain.ConnectivityResult) error {
+	return tmpl.Execute(w, r.newReport(result))
+}
+
+type report struct {
+	Timestamp    string
+	ConfigSource string
+	Generator    string
+	Connected    bool
+	Mode         string
+	Summary      string
+	Issues       string
+	Host         *host
+	Certificates []certificate
+	Findings     []finding
+	Groups       []group
+}
+
+type host struct {
+	SystemID     string
+	Hostname     string
+	SerialNumber string
+	OS           string
+	TLSSession   string
+	RoutingTable string
+}
+
+type certificate struct {
+	Subject   string
+	Issuer    string
+	NotBefore string
+	NotAfter  string
+	Valid     bool
+}
+
+type finding struct {
+	Title       string
+	Severity    string
+	Remediation string
+	Evidence    string
+}
+
+type group struct {
+	Title  string
+	Probes []probe
+}
+
+type probe struct {
+	Name    string
+	Target  string
+	Kind    string
+	Status  string
+	Class   string // pass, warn, fail or skip, selects the status color
+	Latency string
+	Details string
+	Error   string // full error text, never truncated
+}
+
+func (r *Renderer) newReport(result domain.ConnectivityResult) report {
+	rep := report{
+		Timestamp:    result.Timestamp.Format(time.RFC1123),
+		ConfigSource: r.configSource,
+		Generator:    r.generator,
+		Connected:    result.IsConnected,
+		Mode:         result.Mode.String(),
+		Summary:      result.Summary,
+		Issues:       result.IssueSummary(),
+	}
+
+	certs := result.CertificateChain()
+	if r.host != nil {
+		rep.Host = &host{
+			SystemID:     strings.TrimSpace(r.host.SID),
+			Hostname:     r.host.Hostname,
+			SerialNumber: r.host.SN,
+			OS:           r.host.OS,
+			RoutingTable: strings.TrimSpace(r.host.RT),
+		}
+		if r.host.TLSVersion != "" {
+			rep.Host.TLSSession = r.host.TLSVersion + ", " + r.host.TLSCipherSuite
+			if r.host.TLSALPN != "" {
+				rep.Host.TLSSession += ", ALPN " + r.host.TLSALPN
+			}
+		}
+		if len(r.host.TLSCert) > 0 {
+			certs = r.host.TLSCert
+		}
+	}
+	for _, c := range certs {
+		rep.Certificates = append(rep.Certificates, certificate{
+			Subject:   c.Subject,
+			Issuer:    c.Issuer,
+			NotBefore: c.NotBefore.Format(time.DateOnly),
+			NotAfter:  c.NotAfter.Format(time.DateOnly),
+			Valid:     c.Valid,
+		})
+	}
+
+	for _, f := range result.Findings {
+		names := make([]string, 0, len(f.Evidence))
+		for _, p := range f.Evidence {
+			names = append(names, probeName(p))
+		}
+		rep.Findings = append(rep.Findings, finding{
+			Title:       f.Title,
+			Severity:    f.Severity.String(),
+			Remediation: f.Remediation,
+			Evidence:    strings.Join(names, ", "),
+		})
+	}
+
+	vpn, direct, proxy := domain.GroupProbes(result.Probes)
+	for _, g := range []struct {
+		group  domain.ProbeGroup
+		probes []domain.Probe
+	}{
+		{domain.GroupVPN, vpn},
+		{domain.GroupDirect, direct},
+		{domain.GroupProxy, proxy},
+	} {
+		if len(g.probes) == 0 {
+			continue
+		}
+		out := group{Title: g.group.Title()}
+		for _, p := range g.probes {
+			out.Probes = append(out.Probes, newProbe(p))
+		}
+		rep.Groups = append(rep.Groups, out)
+	}
+	return rep
+}
+
+func newProbe(p domain.Probe) probe {
+	out := probe{
+		Name:    probeName(p),
+		Target:  p.Endpoint.Target,
+		Kind:    strings.ToLower(p.Endpoint.TargetType.String()),
+		Status:  p.Status.String(),
+		Latency: "-",
+		Error:   p.Error,
+	}
+	switch {
+	case p.IsSkipped():
+		out.Class = "skip"
+	case p.IsWarning():
+		out.Class = "warn"
+	case p.IsSuccessful():
+		out.Class = "pass"
+	default:
+		out.Class = "fail"
+	}
+	if p.IsSuccessful() {
+		out.Latency = fmt.Sprintf("%.2f ms", p.LatencyMs)
+	}
+	var details []string
+	if st := p.Stats; st != nil && st.Received > 0 {
+		details = append(details, fmt.Sprintf("n=%d min/avg/max %.1f/%.1f/%.1f ms, jitter %.1f ms, loss %.0f%%",
+			st.Samples, st.MinMs, st.AvgMs, st.MaxMs, st.JitterMs, st.LossPercent))
+	}
+	if e := p.Echo; e != nil {
+		details = append(details, fmt.Sprintf("%d/%d echo replies", e.Received, e.Sent))
+	}
+	if n := p.PassedOnRetry(); n > 0 {
+		details = append(details, fmt.Sprintf("passed on retry %d", n))
+	}
+	out.Details = strings.Join(details, "; ")
+	return out
+}
+
+func probeName(p domain.Probe) string {
+	if p.Endpoint.Description != "" {
+		return p.Endpoint.Description
+	}
+	return p.Endpoint.Target
+}
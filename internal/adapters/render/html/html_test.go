@@ -0,0 +1,65 @@
+package html
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func render(t *testing.T, result domain.ConnectivityResult, opts ...Option) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := NewRenderer(opts...).Render(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRenderHasNoExternalAssets(t *testing.T) {
+	result := domain.ConnectivityResult{
+		Mode:        domain.ModeDirect,
+		IsConnected: true,
+		Probes: []domain.Probe{
+			domain.NewSuccessfulProbe(domain.MustNewHTTPEndpoint("https://example.com", domain.EndpointTypePublic, false, "", ""), 20),
+		},
+	}
+	out := render(t, result, WithHostInfo(domain.HostInfo{Hostname: "host-1"}))
+
+	// the report is mailed and opened offline, it must not load anything
+	for _, re := range []*regexp.Regexp{
+		regexp.MustCompile(`(?i)<(script|link|img|iframe|object|embed)\b`),
+		regexp.MustCompile(`(?i)\bsrc\s*=`),
+		regexp.MustCompile(`(?i)@import`),
+		regexp.MustCompile(`(?i)url\(`),
+	} {
+		if loc := re.FindStringIndex(out); loc != nil {
+			t.Errorf("report references an external asset: %q", out[max(0, loc[0]-40):min(len(out), loc[1]+40)])
+		}
+	}
+	if !strings.Contains(out, "<style") {
+		t.Error("report has no inline styles")
+	}
+}
+
+func TestRenderEscapesErrorText(t *testing.T) {
+	msg := `bad response: <script>alert("x")</script> & <b>bold</b>`
+	result := domain.ConnectivityResult{
+		Probes: []domain.Probe{
+			domain.NewFailedProbe(domain.MustNewTCPEndpoint("example.com:443", domain.EndpointTypePublic, `<i>desc</i>`), domain.StatusFail, errors.New(msg)),
+		},
+	}
+	out := render(t, result)
+
+	for _, raw := range []string{`<script>alert`, `<b>bold</b>`, `<i>desc</i>`} {
+		if strings.Contains(out, raw) {
+			t.Errorf("report contains unescaped %q", raw)
+		}
+	}
+	if !strings.Contains(out, `&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &lt;b&gt;bold&lt;/b&gt;`) {
+		t.Errorf("report does not contain the escaped error text:\n%s", out)
+	}
+}
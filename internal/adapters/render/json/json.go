@@ -0,0 +1,300 @@
+package json
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// SchemaVersion is bumped whenever a field is renamed or removed.
+// Adding optional fields does not change the version.
+const SchemaVersion = "1"
+
+type Report struct {
+	SchemaVersion string         `json:"schemaVersion"`
+	Timestamp     time.Time      `json:"timestamp"`
+	Mode          string         `json:"mode"`
+	IsConnected   bool           `json:"isConnected"`
+	Summary       string         `json:"summary"`
+	Issues        string         `json:"issues,omitempty"`
+	StatusCounts  map[string]int `json:"statusCounts"`
+	ConfigSource  string         `json:"configSource,omitempty"`
+	Probes        []Probe        `json:"probes"`
+	Findings      []Finding      `json:"findings"`
+	Host          *Host          `json:"host,omitempty"`
+}
+
+type Probe struct {
+	Endpoint     Endpoint      `json:"endpoint"`
+	Status       string        `json:"status"`
+	LatencyMs    float64       `json:"latencyMs"`
+	Error        string        `json:"error,omitempty"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Certificates []Certificate `json:"certificates,omitempty"`
+	TLSSession   *TLSSession   `json:"tlsSession,omitempty"`
+	TLSPaths     []TLSPath     `json:"tlsPaths,omitempty"`
+	Echo         *Echo         `json:"echo,omitempty"`
+	Stats        *Stats        `json:"stats,omitempty"`
+	Attempts     []Attempt     `json:"attempts,omitempty"`
+}
+
+// TLSPath is the chain one path presented, TLS probes detecting interception only.
+type TLSPath struct {
+	Via          string        `json:"via"`
+	Intercepted  bool          `json:"intercepted"`
+	Error        string        `json:"error,omitempty"`
+	Certificates []Certificate `json:"certificates,omitempty"`
+}
+
+type Attempt struct {
+	Status    string    `json:"status"`
+	LatencyMs float64   `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type Stats struct {
+	Samples     int     `json:"samples"`
+	Received    int     `json:"received"`
+	MinMs       float64 `json:"minMs"`
+	AvgMs       float64 `json:"avgMs"`
+	MaxMs       float64 `json:"maxMs"`
+	StdDevMs    float64 `json:"stdDevMs"`
+	JitterMs    float64 `json:"jitterMs"`
+	LossPercent float64 `json:"lossPercent"`
+}
+
+type Echo struct {
+	Sent        int         `json:"sent"`
+	Received    int         `json:"received"`
+	LossPercent float64     `json:"lossPercent"`
+	Replies     []EchoReply `json:"replies"`
+}
+
+type EchoReply struct {
+	Seq   int     `json:"seq"`
+	RTTMs float64 `json:"rttMs"`
+	TTL   int     `json:"ttl,omitempty"`
+}
+
+type Finding struct {
+	RuleID      string     `json:"ruleId"`
+	Title       string     `json:"title"`
+	Severity    string     `json:"severity"`
+	Remediation string     `json:"remediation,omitempty"`
+	Evidence    []Endpoint `json:"evidence"`
+}
+
+type Endpoint struct {
+	Target      string `json:"target"`
+	Kind        string `json:"kind"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Proxy       string `json:"proxy,omitempty"`
+}
+
+type Host struct {
+	SystemID        string        `json:"systemId"`
+	Hostname        string        `json:"hostname"`
+	SerialNumber    string        `json:"serialNumber,omitempty"`
+	OS              string        `json:"os"`
+	RoutingTable    string        `json:"routingTable,omitempty"`
+	TLSSession      *TLSSession   `json:"tlsSession,omitempty"`
+	TLSCertificates []Certificate `json:"tlsCertificates"`
+}
+
+type TLSSession struct {
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipherSuite"`
+	ALPN        string `json:"alpn,omitempty"`
+}
+
+type Certificate struct {
+	ChainIndex         int       `json:"chainIndex"`
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	DNSNames           []string  `json:"dnsNames,omitempty"`
+	IPAddresses        []string  `json:"ipAddresses,omitempty"`
+	SerialNumber       string    `json:"serialNumber,omitempty"`
+	NotBefore          time.Time `json:"notBefore"`
+	NotAfter           time.Time `json:"notAfter"`
+	Valid              bool      `json:"valid"`
+	IsCA               bool      `json:"isCA"`
+	PublicKeyAlgorithm string    `json:"publicKeyAlgorithm,omitempty"`
+	PublicKeyBits      int       `json:"publicKeyBits,omitempty"`
+	SignatureAlgorithm string    `json:"signatureAlgorithm,omitempty"`
+	SPKISHA256         string    `json:"spkiSha256,omitempty"`
+	SHA256             string    `json:"sha256,omitempty"`
+}
+
+type Renderer struct {
+	host         *domain.HostInfo
+	configSource string
+	indent       bool
+}
+
+type Option func(*Renderer)
+
+func WithHostInfo(h domain.HostInfo) Option { return func(r *Renderer) { r.host = &h } }
+func WithConfigSource(s string) Option      { return func(r *Renderer) { r.configSource = s } }
+func WithIndent(v bool) Option              { return func(r *Renderer) { r.indent = v } }
+
+var _ domain.Renderer = (*Renderer)(nil)
+
+func NewRenderer(opts ...Option) *Renderer {
+	r := &Renderer{indent: true}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Renderer) Render(w io.Writer, result domain.ConnectivityResult) error {
+	enc := json.NewEncoder(w)
+	if r.indent {
+		enc.SetIndent("", "  ")
+	}
+	rep := NewReport(result, r.host)
+	rep.ConfigSource = r.configSource
+	return enc.Encode(rep)
+}
+
+// NewReport converts the domain result into the versioned JSON schema.
+// host may be nil, in which case the host block is omitted.
+func NewReport(result domain.ConnectivityResult, host *domain.HostInfo) Report {
+	rep := Report{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     result.Timestamp,
+		Mode:          result.Mode.String(),
+		IsConnected:   result.IsConnected,
+		Summary:       result.Summary,
+		Issues:        result.IssueSummary(),
+		StatusCounts:  make(map[string]int),
+		Probes:        make([]Probe, 0, len(result.Probes)),
+		Findings:      make([]Finding, 0, len(result.Findings)),
+	}
+	for st, n := range result.StatusCounts() {
+		rep.StatusCounts[st.Key()] = n
+	}
+	for _, p := range result.Probes {
+		rep.Probes = append(rep.Probes, newProbe(p))
+	}
+	for _, f := range result.Findings {
+		rep.Findings = append(rep.Findings, newFinding(f))
+	}
+	if host != nil {
+		rep.Host = NewHost(*host)
+	}
+	return rep
+}
+
+func newProbe(p domain.Probe) Probe {
+	out := Probe{
+		Endpoint:  newEndpoint(p.Endpoint),
+		Status:    p.Status.Key(),
+		LatencyMs: p.LatencyMs,
+		Error:     p.Error,
+		Timestamp: p.Timestamp,
+	}
+	for _, c := range p.Certificates {
+		out.Certificates = append(out.Certificates, newCertificate(c))
+	}
+	if p.Echo != nil {
+		out.Echo = &Echo{
+			Sent:        p.Echo.Sent,
+			Received:    p.Echo.Received,
+			LossPercent: p.Echo.LossPercent(),
+			Replies:     make([]EchoReply, 0, len(p.Echo.Replies)),
+		}
+		for _, r := range p.Echo.Replies {
+			out.Echo.Replies = append(out.Echo.Replies, EchoReply{Seq: r.Seq, RTTMs: r.RTTMs, TTL: r.TTL})
+		}
+	}
+	if p.Stats != nil {
+		st := Stats(*p.Stats)
+		out.Stats = &st
+	}
+	if ts := p.TLSSession; ts != nil {
+		out.TLSSession = &TLSSession{Version: ts.Version, CipherSuite: ts.CipherSuite, ALPN: ts.ALPN}
+	}
+	for _, path := range p.TLSPaths {
+		tp := TLSPath{Via: path.Name(), Intercepted: path.Intercepted, Error: path.Error}
+		for _, c := range path.Certificates {
+			tp.Certificates = append(tp.Certificates, newCertificate(c))
+		}
+		out.TLSPaths = append(out.TLSPaths, tp)
+	}
+	for _, a := range p.Attempts {
+		out.Attempts = append(out.Attempts, Attempt{Status: a.Status.Key(), LatencyMs: a.LatencyMs, Error: a.Error, Timestamp: a.Timestamp})
+	}
+	return out
+}
+
+func newFinding(f domain.Finding) Finding {
+	out := Finding{
+		RuleID:      f.RuleID,
+		Title:       f.Title,
+		Severity:    f.Severity.String(),
+		Remediation: f.Remediation,
+		Evidence:    make([]Endpoint, 0, len(f.Evidence)),
+	}
+	for _, p := range f.Evidence {
+		out.Evidence = append(out.Evidence, newEndpoint(p.Endpoint))
+	}
+	return out
+}
+
+func newEndpoint(ep domain.Endpoint) Endpoint {
+	e := Endpoint{
+		Target:      ep.Target,
+		Kind:        strings.ToLower(ep.TargetType.String()),
+		Type:        ep.Type.String(),
+		Description: ep.Description,
+	}
+	if ep.MustUseProxy() {
+		e.Proxy = ep.Proxy.URL()
+	}
+	return e
+}
+
+// NewHost converts host information into its JSON form.
+func NewHost(h domain.HostInfo) *Host {
+	out := &Host{
+		SystemID:        strings.TrimSpace(h.SID),
+		Hostname:        h.Hostname,
+		SerialNumber:    h.SN,
+		OS:              h.OS,
+		RoutingTable:    strings.TrimSpace(h.RT),
+		TLSCertificates: make([]Certificate, 0, len(h.TLSCert)),
+	}
+	for _, c := range h.TLSCert {
+		out.TLSCertificates = append(out.TLSCertificates, newCertificate(c))
+	}
+	if h.TLSVersion != "" {
+		out.TLSSession = &TLSSession{Version: h.TLSVersion, CipherSuite: h.TLSCipherSuite, ALPN: h.TLSALPN}
+	}
+	return out
+}
+
+func newCertificate(c domain.TLSCertificate) Certificate {
+	return Certificate{
+		ChainIndex:         c.ChainIndex,
+		Subject:            c.Subject,
+		Issuer:             c.Issuer,
+		DNSNames:           c.DNSNames,
+		IPAddresses:        c.IPAddresses,
+		SerialNumber:       c.SerialNumber,
+		NotBefore:          c.NotBefore,
+		NotAfter:           c.NotAfter,
+		Valid:              c.Valid,
+		IsCA:               c.IsCA,
+		PublicKeyAlgorithm: c.PublicKeyAlgorithm,
+		PublicKeyBits:      c.PublicKeyBits,
+		SignatureAlgorithm: c.SignatureAlgorithm,
+		SPKISHA256:         c.SPKISHA256,
+		SHA256:             c.FingerprintSHA256,
+	}
+}
@@ -0,0 +1,119 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenResult covers every block of the report, so the golden file pins all
+// field names a consumer may depend on.
+func goldenResult() (domain.ConnectivityResult, domain.HostInfo) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	pass := domain.NewSuccessfulProbe(domain.MustNewTCPEndpoint("example.com:443", domain.EndpointTypePublic, "public https"), 12.5)
+	pass.Timestamp = ts
+	pass.Stats = &domain.ProbeStats{Samples: 4, Received: 4, MinMs: 10, AvgMs: 12.5, MaxMs: 15, StdDevMs: 2, JitterMs: 1.5}
+	fail := domain.NewFailedProbe(
+		domain.MustNewHTTPEndpoint("http://intranet.local", domain.EndpointTypeVPN, true, "http://proxy.local:3128", ""),
+		domain.StatusTimeout, errors.New("context deadline exceeded"))
+	fail.Timestamp = ts
+	fail.Attempts = []domain.Attempt{domain.NewAttempt(fail), domain.NewAttempt(fail)}
+	intercepted := domain.NewFailedProbe(
+		domain.MustNewTLSEndpoint("api.example.com:443", domain.EndpointTypePublic, "", []string{"proxy.local:3128"}, ""),
+		domain.StatusFail, errors.New(`TLS interception (via proxy.local:3128): certificate "CN=api.example.com" issued by "CN=Inspecting CA"`))
+	intercepted.Timestamp = ts
+	expected := domain.TLSCertificate{
+		Subject:            "CN=api.example.com",
+		Issuer:             "CN=Example CA",
+		DNSNames:           []string{"api.example.com"},
+		IPAddresses:        []string{"192.0.2.10"},
+		SerialNumber:       "1A2B",
+		NotBefore:          ts.AddDate(0, -1, 0),
+		NotAfter:           ts.AddDate(0, 2, 0),
+		Valid:              true,
+		PublicKeyAlgorithm: "ECDSA",
+		PublicKeyBits:      256,
+		SignatureAlgorithm: "ECDSA-SHA256",
+		SPKISHA256:         "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=",
+		FingerprintSHA256:  "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}
+	forged := expected
+	forged.Issuer = "CN=Inspecting CA"
+	intercepted.Certificates = []domain.TLSCertificate{forged}
+	intercepted.TLSSession = &domain.TLSSession{Version: "TLS 1.3", CipherSuite: "TLS_AES_128_GCM_SHA256", ALPN: "h2"}
+	intercepted.TLSPaths = []domain.TLSPath{
+		{Certificates: []domain.TLSCertificate{expected}},
+		{Via: "proxy.local:3128", Certificates: []domain.TLSCertificate{forged}, Intercepted: true},
+	}
+
+	result := domain.ConnectivityResult{
+		Mode:        domain.ModeDirect,
+		IsConnected: true,
+		Probes:      []domain.Probe{pass, fail, intercepted},
+		Timestamp:   ts,
+		Summary:     "direct internet access",
+		Findings: []domain.Finding{{
+			RuleID:      "vpn-down",
+			Title:       "VPN endpoints do not answer",
+			Severity:    domain.SeverityCritical,
+			Remediation: "Reconnect the VPN client.",
+			Evidence:    []domain.Probe{fail},
+		}},
+	}
+	host := domain.HostInfo{
+		SID:            "sid-1\n",
+		Hostname:       "host-1",
+		OS:             "linux",
+		RT:             "default via 10.0.0.1\n",
+		TLSVersion:     "TLS 1.3",
+		TLSCipherSuite: "TLS_AES_128_GCM_SHA256",
+		TLSALPN:        "h2",
+		TLSCert: []domain.TLSCertificate{{
+			Subject:   "CN=example.com",
+			Issuer:    "CN=Example CA",
+			NotBefore: ts.AddDate(0, -1, 0),
+			NotAfter:  ts.AddDate(0, 2, 0),
+			Valid:     true,
+		}},
+	}
+	return result, host
+}
+
+func TestReportGolden(t *testing.T) {
+	result, host := goldenResult()
+	var buf bytes.Buffer
+	if err := NewRenderer(WithHostInfo(host)).Render(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "report.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("report differs from %s, a renamed or removed field needs a new SchemaVersion:\n%s", golden, buf.String())
+	}
+}
+
+func TestReportWithoutHost(t *testing.T) {
+	result, _ := goldenResult()
+	rep := NewReport(result, nil)
+	if rep.SchemaVersion != SchemaVersion || rep.Host != nil {
+		t.Errorf("report = %+v, want schema %s without host", rep, SchemaVersion)
+	}
+}
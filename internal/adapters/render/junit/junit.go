@@ -0,0 +1,181 @@
+// Package junit writes results as JUnit XML so CI systems show one test
+// case per endpoint: a test suite per group, a test case per probe.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     Seconds     `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+type TestSuite struct {
+	Name       string     `xml:"name,attr"`
+	Tests      int        `xml:"tests,attr"`
+	Failures   int        `xml:"failures,attr"`
+	Skipped    int        `xml:"skipped,attr"`
+	Time       Seconds    `xml:"time,attr"`
+	Timestamp  string     `xml:"timestamp,attr"`
+	Hostname   string     `xml:"hostname,attr,omitempty"`
+	Properties []Property `xml:"properties>property,omitempty"`
+	Cases      []TestCase `xml:"testcase"`
+}
+
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Time      Seconds  `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// Seconds is written with millisecond precision and without exponent,
+// some JUnit consumers reject "8e-05".
+type Seconds float64
+
+func (s Seconds) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: strconv.FormatFloat(float64(s), 'f', 3, 64)}, nil
+}
+
+type Renderer struct {
+	host *domain.HostInfo
+}
+
+type Option func(*Renderer)
+
+// WithHostInfo adds the hostname and system ID to every test suite.
+func WithHostInfo(h domain.HostInfo) Option { return func(r *Renderer) { r.host = &h } }
+
+var _ domain.Renderer = (*Renderer)(nil)
+
+func NewRenderer(opts ...Option) *Renderer {
+	r := &Renderer{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Renderer) Render(w io.Writer, result domain.ConnectivityResult) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(r.NewTestSuites(result)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// NewTestSuites maps the groups to test suites in the order of the table
+// output, groups without probes are left out.
+func (r *Renderer) NewTestSuites(result domain.ConnectivityResult) TestSuites {
+	vpn, direct, proxy := domain.GroupProbes(result.Probes)
+	out := TestSuites{Name: "rsvpck"}
+	for _, g := range []struct {
+		group  domain.ProbeGroup
+		probes []domain.Probe
+	}{
+		{domain.GroupVPN, vpn},
+		{domain.GroupDirect, direct},
+		{domain.GroupProxy, proxy},
+	} {
+		if len(g.probes) == 0 {
+			continue
+		}
+		suite := r.newSuite(result, g.group, g.probes)
+		out.Tests += suite.Tests
+		out.Failures += suite.Failures
+		out.Skipped += suite.Skipped
+		out.Time += suite.Time
+		out.Suites = append(out.Suites, suite)
+	}
+	return out
+}
+
+func (r *Renderer) newSuite(result domain.ConnectivityResult, group domain.ProbeGroup, probes []domain.Probe) TestSuite {
+	suite := TestSuite{
+		Name:      group.Title(),
+		Timestamp: result.Timestamp.Format("2006-01-02T15:04:05"),
+		Properties: []Property{
+			{Name: "mode", Value: result.Mode.String()},
+			{Name: "summary", Value: result.Summary},
+		},
+	}
+	if r.host != nil {
+		suite.Hostname = r.host.Hostname
+		suite.Properties = append(suite.Properties, Property{Name: "systemId", Value: strings.TrimSpace(r.host.SID)})
+	}
+
+	for _, p := range probes {
+		tc := newTestCase(group, p)
+		suite.Tests++
+		suite.Time += tc.Time
+		switch {
+		case tc.Skipped != nil:
+			suite.Skipped++
+		case tc.Failure != nil:
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	return suite
+}
+
+func newTestCase(group domain.ProbeGroup, p domain.Probe) TestCase {
+	name := p.Endpoint.Description
+	if name == "" {
+		name = p.Endpoint.Target
+	}
+	kind := strings.ToLower(p.Endpoint.TargetType.String())
+	tc := TestCase{
+		Name:      name,
+		Classname: fmt.Sprintf("rsvpck.%s.%s", group, kind),
+		Time:      Seconds(p.LatencyMs / 1000),
+	}
+
+	switch {
+	case p.IsSkipped():
+		tc.Skipped = &Skipped{Message: p.Error}
+	case !p.IsSuccessful():
+		tc.Failure = &Failure{
+			Message: fmt.Sprintf("%s: %s", p.Status, p.Error),
+			Type:    p.Status.Key(),
+			Text:    fmt.Sprintf("%s %s\n%s", kind, p.Endpoint.Target, p.Error),
+		}
+	case p.IsWarning():
+		tc.SystemOut = "warning: " + p.Error
+	}
+	return tc
+}
@@ -0,0 +1,90 @@
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func TestRender(t *testing.T) {
+	errText := `unexpected body "]]><x>" & more`
+	warn := domain.NewSuccessfulProbe(domain.MustNewTCPEndpoint("slow.example:443", domain.EndpointTypePublic, ""), 900)
+	warn.Status = domain.StatusWarning
+	warn.Error = "latency above the limit"
+	result := domain.ConnectivityResult{
+		Mode: domain.ModeViaVPN,
+		Probes: []domain.Probe{
+			domain.NewSuccessfulProbe(domain.MustNewTCPEndpoint("example.com:443", domain.EndpointTypePublic, "public https"), 12),
+			domain.NewFailedProbe(domain.MustNewTCPEndpoint("bad.example:443", domain.EndpointTypePublic, ""), domain.StatusHTTPError, errors.New(errText)),
+			warn,
+			domain.NewSuccessfulProbe(domain.MustNewICMPEndpoint("10.0.0.1", domain.EndpointTypeVPN, ""), 3),
+			domain.NewSkippedProbe(domain.MustNewTCPEndpoint("10.0.0.2:22", domain.EndpointTypeVPN, ""), "no ICMP endpoint of this group answered"),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewRenderer(WithHostInfo(domain.HostInfo{Hostname: "host-1"})).Render(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("output does not start with the XML header:\n%s", buf.String())
+	}
+
+	// decoding is what a CI system does, it fails on malformed XML
+	var got TestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not well-formed XML: %v\n%s", err, buf.String())
+	}
+	if got.Tests != 5 || got.Failures != 1 || got.Skipped != 1 {
+		t.Errorf("testsuites: tests %d, failures %d, skipped %d, want 5, 1, 1", got.Tests, got.Failures, got.Skipped)
+	}
+
+	want := []struct {
+		name                     string
+		tests, failures, skipped int
+	}{
+		{"VPN Connectivity", 2, 0, 1},
+		{"Direct Internet", 3, 1, 0},
+	}
+	if len(got.Suites) != len(want) {
+		t.Fatalf("got %d suites, want %d, groups without probes are left out", len(got.Suites), len(want))
+	}
+	for i, w := range want {
+		s := got.Suites[i]
+		if s.Name != w.name || s.Tests != w.tests || s.Failures != w.failures || s.Skipped != w.skipped || len(s.Cases) != w.tests {
+			t.Errorf("suite %d = %s: tests %d, failures %d, skipped %d, want %s: %d, %d, %d",
+				i, s.Name, s.Tests, s.Failures, s.Skipped, w.name, w.tests, w.failures, w.skipped)
+		}
+		if s.Hostname != "host-1" {
+			t.Errorf("suite %s hostname = %q, want host-1", s.Name, s.Hostname)
+		}
+	}
+
+	direct := got.Suites[1].Cases
+	if direct[0].Name != "public https" || direct[0].Classname != "rsvpck.direct.tcp" || direct[0].Time != 0.012 {
+		t.Errorf("passed case = %+v, want the description as name and the latency as time", direct[0])
+	}
+	if f := direct[1].Failure; f == nil || f.Type != "http_error" || !strings.Contains(f.Text, errText) {
+		t.Errorf("failed case = %+v, want an http_error failure with the full error text", direct[1])
+	}
+	if direct[2].Failure != nil || direct[2].SystemOut != "warning: latency above the limit" {
+		t.Errorf("warning case = %+v, want a pass with the warning in system-out", direct[2])
+	}
+	if s := got.Suites[0].Cases[1].Skipped; s == nil || s.Message == "" {
+		t.Errorf("skipped case = %+v, want <skipped> with the reason", got.Suites[0].Cases[1])
+	}
+}
+
+func TestSecondsHasNoExponent(t *testing.T) {
+	out, err := xml.Marshal(TestCase{Name: "x", Time: Seconds(0.00008)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `time="0.000"`) {
+		t.Errorf("marshalled %s, want time=\"0.000\"", out)
+	}
+}
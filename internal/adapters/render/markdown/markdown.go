@@ -0,0 +1,191 @@
+// Package markdown writes results as GitHub flavoured Markdown for pasting
+// into support tickets.
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+type Renderer struct {
+	host         *domain.HostInfo
+	configSource string
+}
+
+type Option func(*Renderer)
+
+func WithHostInfo(h domain.HostInfo) Option { return func(r *Renderer) { r.host = &h } }
+func WithConfigSource(s string) Option      { return func(r *Renderer) { r.configSource = s } }
+
+var _ domain.Renderer = (*Renderer)(nil)
+
+func NewRenderer(opts ...Option) *Renderer {
+	r := &Renderer{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Renderer) Render(w io.Writer, result domain.ConnectivityResult) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "## RSvP connectivity report\n\n")
+	state := "❌ **Not connected**"
+	if result.IsConnected {
+		state = "✅ **Connected**"
+	}
+	fmt.Fprintf(bw, "%s, mode `%s`: %s\n", state, result.Mode, result.Summary)
+	if issues := result.IssueSummary(); issues != "" {
+		fmt.Fprintf(bw, "Issues: %s\n", issues)
+	}
+	fmt.Fprintf(bw, "\n_%s", result.Timestamp.Format(time.RFC1123))
+	if r.configSource != "" {
+		fmt.Fprintf(bw, ", config: %s", escapeCell(r.configSource))
+	}
+	fmt.Fprintf(bw, "_\n")
+
+	if r.host != nil {
+		r.renderHost(bw, *r.host)
+	}
+	if len(result.Findings) > 0 {
+		renderFindings(bw, result.Findings)
+	}
+
+	vpn, direct, proxy := domain.GroupProbes(result.Probes)
+	for _, g := range []struct {
+		group  domain.ProbeGroup
+		probes []domain.Probe
+	}{
+		{domain.GroupVPN, vpn},
+		{domain.GroupDirect, direct},
+		{domain.GroupProxy, proxy},
+	} {
+		if len(g.probes) > 0 {
+			renderGroup(bw, g.group.Title(), g.probes)
+		}
+	}
+	return bw.Flush()
+}
+
+func (r *Renderer) renderHost(w io.Writer, h domain.HostInfo) {
+	fmt.Fprintf(w, "\n### System information\n\n| | |\n|---|---|\n")
+	fmt.Fprintf(w, "| System ID | %s |\n", escapeCell(strings.TrimSpace(h.SID)))
+	fmt.Fprintf(w, "| Hostname | %s |\n", escapeCell(h.Hostname))
+	if h.SN != "" {
+		fmt.Fprintf(w, "| Serial number | %s |\n", escapeCell(h.SN))
+	}
+	fmt.Fprintf(w, "| Operating system | %s |\n", escapeCell(h.OS))
+	if h.TLSVersion != "" {
+		fmt.Fprintf(w, "| TLS session | %s |\n", escapeCell(tlsSession(h)))
+	}
+	if rt := strings.TrimSpace(h.RT); rt != "" {
+		fmt.Fprintf(w, "\n<details><summary>Routing table</summary>\n\n%s\n\n</details>\n", codeBlock(rt))
+	}
+	if len(h.TLSCert) > 0 {
+		fmt.Fprintf(w, "\n**TLS certificate chain**\n\n| # | Subject | Issuer | Not after | Valid |\n|---:|---|---|---|---|\n")
+		for i, c := range h.TLSCert {
+			fmt.Fprintf(w, "| %d | %s | %s | %s | %s |\n", i, escapeCell(c.Subject), escapeCell(c.Issuer),
+				c.NotAfter.Format(time.DateOnly), yesNo(c.Valid))
+		}
+	}
+}
+
+func renderFindings(w io.Writer, findings []domain.Finding) {
+	fmt.Fprintf(w, "\n### Diagnosis\n\n| Diagnosis | Severity | Remediation |\n|---|---|---|\n")
+	for _, f := range findings {
+		fmt.Fprintf(w, "| %s | %s | %s |\n", escapeCell(f.Title), f.Severity, escapeCell(f.Remediation))
+	}
+}
+
+// renderGroup writes the probe table followed by a collapsed block with the
+// full error text, table cells only hold the status.
+func renderGroup(w io.Writer, title string, probes []domain.Probe) {
+	fmt.Fprintf(w, "\n### %s\n\n| | Endpoint | Target | Status | Latency |\n|---|---|---|---|---:|\n", title)
+	var withErrors []domain.Probe
+	for _, p := range probes {
+		latency := "-"
+		if p.IsSuccessful() {
+			latency = fmt.Sprintf("%.2f ms", p.LatencyMs)
+		}
+		fmt.Fprintf(w, "| %s | %s | `%s` | %s | %s |\n",
+			statusEmoji(p), escapeCell(probeName(p)), escapeCell(p.Endpoint.Target), p.Status, latency)
+		if p.Error != "" {
+			withErrors = append(withErrors, p)
+		}
+	}
+
+	if len(withErrors) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n<details><summary>Errors (%d)</summary>\n\n", len(withErrors))
+	for _, p := range withErrors {
+		fmt.Fprintf(w, "**%s** (%s)\n\n%s\n\n", escapeCell(probeName(p)), p.Status, codeBlock(p.Error))
+	}
+	fmt.Fprintf(w, "</details>\n")
+}
+
+func statusEmoji(p domain.Probe) string {
+	switch {
+	case p.IsSkipped():
+		return "⏭️"
+	case p.IsWarning():
+		return "⚠️"
+	case p.IsSuccessful():
+		return "✅"
+	default:
+		return "❌"
+	}
+}
+
+func probeName(p domain.Probe) string {
+	if p.Endpoint.Description != "" {
+		return p.Endpoint.Description
+	}
+	return p.Endpoint.Target
+}
+
+var cellEscaper = strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;", "\n", " ", "\r", "")
+
+// escapeCell keeps a value inside one table cell and stops it from being
+// read as inline HTML.
+func escapeCell(s string) string {
+	return cellEscaper.Replace(s)
+}
+
+// codeBlock fences s with more backticks than its longest backtick run, so
+// backticks in error texts cannot close the block early.
+func codeBlock(s string) string {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "\n" + s + "\n" + fence
+}
+
+// tlsSession is e.g. "TLS 1.3, TLS_AES_128_GCM_SHA256, ALPN h2".
+func tlsSession(h domain.HostInfo) string {
+	s := h.TLSVersion + ", " + h.TLSCipherSuite
+	if h.TLSALPN != "" {
+		s += ", ALPN " + h.TLSALPN
+	}
+	return s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
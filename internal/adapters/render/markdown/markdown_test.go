@@ -0,0 +1,60 @@
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func TestCodeBlock(t *testing.T) {
+	tests := []struct {
+		in, fence string
+	}{
+		{"plain error", "```"},
+		{"uses `code`", "```"},
+		{"closes ``` early", "````"},
+		{"````` five", "``````"},
+		{"ends with ```", "````"},
+	}
+	for _, tt := range tests {
+		got := codeBlock(tt.in)
+		want := tt.fence + "\n" + tt.in + "\n" + tt.fence
+		if got != want {
+			t.Errorf("codeBlock(%q) = %q, want %q", tt.in, got, want)
+		}
+	}
+}
+
+func TestRenderErrorWithBackticks(t *testing.T) {
+	ep := domain.MustNewTCPEndpoint("example.com:443", domain.EndpointTypePublic, "")
+	msg := "bad response:\n```\n</details>\n# injected"
+	result := domain.ConnectivityResult{Probes: []domain.Probe{
+		domain.NewFailedProbe(ep, domain.StatusFail, errors.New(msg)),
+	}}
+
+	var buf bytes.Buffer
+	if err := NewRenderer().Render(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "````\n"+msg+"\n````") {
+		t.Errorf("error is not fenced with four backticks:\n%s", buf.String())
+	}
+}
+
+func TestTLSSession(t *testing.T) {
+	tests := []struct {
+		host domain.HostInfo
+		want string
+	}{
+		{domain.HostInfo{TLSVersion: "TLS 1.3", TLSCipherSuite: "TLS_AES_128_GCM_SHA256", TLSALPN: "h2"}, "TLS 1.3, TLS_AES_128_GCM_SHA256, ALPN h2"},
+		{domain.HostInfo{TLSVersion: "TLS 1.2", TLSCipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}, "TLS 1.2, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+	for _, tt := range tests {
+		if got := tlsSession(tt.host); got != tt.want {
+			t.Errorf("tlsSession = %q, want %q", got, tt.want)
+		}
+	}
+}
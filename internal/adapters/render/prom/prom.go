@@ -0,0 +1,115 @@
+// Package prom writes connectivity results in the Prometheus text
+// exposition format, served by rsvpck metrics or written for the
+// node_exporter textfile collector.
+package prom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+type Renderer struct{}
+
+var _ domain.Renderer = (*Renderer)(nil)
+
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+var modes = []domain.ConnectivityMode{domain.ModeDirect, domain.ModeViaProxy, domain.ModeViaVPN, domain.ModeNone}
+
+// Render writes one sample per probe and mode. Skipped probes have no success
+// or latency sample since they were not measured. Probes with identical labels
+// only report the first one, duplicate series would fail the whole scrape.
+func (r *Renderer) Render(w io.Writer, result domain.ConnectivityResult) error {
+	bw := bufio.NewWriter(w)
+
+	success := newFamily(bw, "rsvpck_probe_success", "Whether the last probe of the endpoint succeeded (1) or failed (0).")
+	for _, p := range result.Probes {
+		if !p.IsSkipped() {
+			success.sample(probeLabels(p), boolValue(p.IsSuccessful()))
+		}
+	}
+
+	latency := newFamily(bw, "rsvpck_probe_latency_seconds", "Latency of the last successful probe of the endpoint.")
+	for _, p := range result.Probes {
+		if p.IsSuccessful() {
+			latency.sample(probeLabels(p), p.LatencyMs/1000)
+		}
+	}
+
+	mode := newFamily(bw, "rsvpck_connectivity_mode", "Connectivity mode of the last run, 1 for the active mode.")
+	for _, m := range modes {
+		mode.sample([]label{{"mode", m.String()}}, boolValue(result.Mode == m))
+	}
+
+	expiry := newFamily(bw, "rsvpck_tls_cert_expiry_seconds", "NotAfter of each presented certificate as unix timestamp.")
+	for _, p := range result.Probes {
+		for _, c := range p.Certificates {
+			expiry.sample(append(probeLabels(p), label{"subject", c.Subject}), float64(c.NotAfter.Unix()))
+		}
+	}
+
+	lastRun := newFamily(bw, "rsvpck_last_run_timestamp_seconds", "Completion time of the last run as unix timestamp.")
+	lastRun.sample(nil, float64(result.Timestamp.UnixMilli())/1000)
+
+	return bw.Flush()
+}
+
+type label struct {
+	name, value string
+}
+
+func probeLabels(p domain.Probe) []label {
+	return []label{
+		{"endpoint", p.Endpoint.Target},
+		{"kind", strings.ToLower(p.Endpoint.TargetType.String())},
+		{"group", p.Group().String()},
+	}
+}
+
+// family writes the samples of one gauge and drops duplicate label sets.
+type family struct {
+	w    io.Writer
+	name string
+	seen map[string]bool
+}
+
+func newFamily(w io.Writer, name, help string) *family {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	return &family{w: w, name: name, seen: make(map[string]bool)}
+}
+
+func (f *family) sample(labels []label, value float64) {
+	var ls string
+	if len(labels) > 0 {
+		parts := make([]string, 0, len(labels))
+		for _, l := range labels {
+			parts = append(parts, l.name+`="`+escapeLabel(l.value)+`"`)
+		}
+		ls = "{" + strings.Join(parts, ",") + "}"
+	}
+	if f.seen[ls] {
+		return
+	}
+	f.seen[ls] = true
+	fmt.Fprintf(f.w, "%s%s %s\n", f.name, ls, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
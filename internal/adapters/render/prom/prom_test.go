@@ -0,0 +1,68 @@
+package prom
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// The metric names and labels are a contract with dashboards and alerts,
+// renaming one needs a CHANGELOG entry.
+func TestRenderMetricNamesAndLabels(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+	notAfter := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	tcp := domain.MustNewTCPEndpoint("example.com:443", domain.EndpointTypePublic, "")
+	vpn := domain.MustNewICMPEndpoint("10.0.0.1", domain.EndpointTypeVPN, "")
+	tlsEp, err := domain.NewTLSEndpoint("api.example.com:443", domain.EndpointTypePublic, "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsProbe := domain.NewSuccessfulProbe(tlsEp, 40)
+	tlsProbe.Certificates = []domain.TLSCertificate{{Subject: `CN=api "example"`, NotAfter: notAfter}}
+
+	result := domain.ConnectivityResult{
+		Mode:      domain.ModeDirect,
+		Timestamp: ts,
+		Probes: []domain.Probe{
+			domain.NewSuccessfulProbe(tcp, 12.5),
+			domain.NewSuccessfulProbe(tcp, 99), // duplicate series, dropped
+			domain.NewFailedProbe(vpn, domain.StatusTimeout, errors.New("no answer")),
+			domain.NewSkippedProbe(domain.MustNewTCPEndpoint("skipped.example:22", domain.EndpointTypeVPN, ""), "gated"),
+			tlsProbe,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := NewRenderer().Render(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP rsvpck_probe_success Whether the last probe of the endpoint succeeded (1) or failed (0).
+# TYPE rsvpck_probe_success gauge
+rsvpck_probe_success{endpoint="example.com:443",kind="tcp",group="direct"} 1
+rsvpck_probe_success{endpoint="10.0.0.1",kind="icmp",group="vpn"} 0
+rsvpck_probe_success{endpoint="api.example.com:443",kind="tls",group="direct"} 1
+# HELP rsvpck_probe_latency_seconds Latency of the last successful probe of the endpoint.
+# TYPE rsvpck_probe_latency_seconds gauge
+rsvpck_probe_latency_seconds{endpoint="example.com:443",kind="tcp",group="direct"} 0.0125
+rsvpck_probe_latency_seconds{endpoint="api.example.com:443",kind="tls",group="direct"} 0.04
+# HELP rsvpck_connectivity_mode Connectivity mode of the last run, 1 for the active mode.
+# TYPE rsvpck_connectivity_mode gauge
+rsvpck_connectivity_mode{mode="direct"} 1
+rsvpck_connectivity_mode{mode="via_proxy"} 0
+rsvpck_connectivity_mode{mode="via_vpn"} 0
+rsvpck_connectivity_mode{mode="none"} 0
+# HELP rsvpck_tls_cert_expiry_seconds NotAfter of each presented certificate as unix timestamp.
+# TYPE rsvpck_tls_cert_expiry_seconds gauge
+rsvpck_tls_cert_expiry_seconds{endpoint="api.example.com:443",kind="tls",group="direct",subject="CN=api \"example\""} 1.7224704e+09
+# HELP rsvpck_last_run_timestamp_seconds Completion time of the last run as unix timestamp.
+# TYPE rsvpck_last_run_timestamp_seconds gauge
+rsvpck_last_run_timestamp_seconds 1.7145648005e+09
+`
+	if got := buf.String(); got != want {
+		t.Errorf("metrics:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// Report is the value a template is executed with, "." at the top level.
+type Report struct {
+	Timestamp    time.Time
+	Connected    bool
+	Mode         string // none, direct, via_proxy or via_vpn
+	Summary      string // e.g. "Connected via VPN."
+	Issues       string // e.g. "3 timeouts, 1 DNS failure", empty when all passed
+	ConfigSource string
+	Host         *Host // nil when no host information was collected
+	Certificates []Certificate
+	Findings     []Finding // ranked by severity
+	Probes       []Probe   // in config order, see the groups func for sections
+}
+
+type Host struct {
+	SystemID     string
+	Hostname     string
+	SerialNumber string
+	OS           string
+	RoutingTable string
+}
+
+type Certificate struct {
+	Subject   string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Valid     bool
+}
+
+type Finding struct {
+	Title       string
+	Severity    string // info, warning or critical
+	Remediation string
+	Evidence    []string // names of the probes the finding is based on
+}
+
+// Probe is one checked endpoint. Exactly one of Passed, Warning, Skipped
+// and Failed is true.
+type Probe struct {
+	Name      string // description, or the target when there is none
+	Target    string
+	Kind      string // tcp, dns, http, icmp or tls
+	Group     string // vpn, direct or proxy
+	Status    string // e.g. "Pass", "Timeout", "DNS Failure"
+	Passed    bool
+	Warning   bool
+	Skipped   bool
+	Failed    bool
+	LatencyMs float64
+	Error     string // full error or warning text
+	Attempts  int    // 1 unless the probe was retried
+	Details   string // sampling, echo and retry summary, may be empty
+}
+
+// Group is a report section as returned by the groups func.
+type Group struct {
+	Name   string // vpn, direct or proxy
+	Title  string // e.g. "VPN Connectivity"
+	Probes []Probe
+}
+
+func (r *Renderer) newReport(result domain.ConnectivityResult) Report {
+	rep := Report{
+		Timestamp:    result.Timestamp,
+		Connected:    result.IsConnected,
+		Mode:         result.Mode.String(),
+		Summary:      result.Summary,
+		Issues:       result.IssueSummary(),
+		ConfigSource: r.configSource,
+	}
+
+	certs := result.CertificateChain()
+	if r.host != nil {
+		rep.Host = &Host{
+			SystemID:     strings.TrimSpace(r.host.SID),
+			Hostname:     r.host.Hostname,
+			SerialNumber: r.host.SN,
+			OS:           r.host.OS,
+			RoutingTable: strings.TrimSpace(r.host.RT),
+		}
+		if len(r.host.TLSCert) > 0 {
+			certs = r.host.TLSCert
+		}
+	}
+	for _, c := range certs {
+		rep.Certificates = append(rep.Certificates, Certificate{
+			Subject:   c.Subject,
+			Issuer:    c.Issuer,
+			NotBefore: c.NotBefore,
+			NotAfter:  c.NotAfter,
+			Valid:     c.Valid,
+		})
+	}
+
+	for _, f := range result.Findings {
+		evidence := make([]string, 0, len(f.Evidence))
+		for _, p := range f.Evidence {
+			evidence = append(evidence, probeName(p))
+		}
+		rep.Findings = append(rep.Findings, Finding{
+			Title:       f.Title,
+			Severity:    f.Severity.String(),
+			Remediation: f.Remediation,
+			Evidence:    evidence,
+		})
+	}
+
+	for _, p := range result.Probes {
+		rep.Probes = append(rep.Probes, newProbe(p))
+	}
+	return rep
+}
+
+func newProbe(p domain.Probe) Probe {
+	out := Probe{
+		Name:      probeName(p),
+		Target:    p.Endpoint.Target,
+		Kind:      strings.ToLower(p.Endpoint.TargetType.String()),
+		Group:     p.Group().String(),
+		Status:    p.Status.String(),
+		Skipped:   p.IsSkipped(),
+		LatencyMs: p.LatencyMs,
+		Error:     p.Error,
+		Attempts:  max(len(p.Attempts), 1),
+	}
+	switch {
+	case out.Skipped:
+	case p.IsWarning():
+		out.Warning = true
+	case p.IsSuccessful():
+		out.Passed = true
+	default:
+		out.Failed = true
+	}
+
+	var details []string
+	if st := p.Stats; st != nil && st.Received > 0 {
+		details = append(details, fmt.Sprintf("n=%d min/avg/max %.1f/%.1f/%.1f ms jitter %.1f ms loss %.0f%%",
+			st.Samples, st.MinMs, st.AvgMs, st.MaxMs, st.JitterMs, st.LossPercent))
+	}
+	if e := p.Echo; e != nil {
+		details = append(details, fmt.Sprintf("%d/%d replies", e.Received, e.Sent))
+	}
+	if n := p.PassedOnRetry(); n > 0 {
+		details = append(details, fmt.Sprintf("passed on retry %d", n))
+	}
+	out.Details = strings.Join(details, ", ")
+	return out
+}
+
+func probeName(p domain.Probe) string {
+	if p.Endpoint.Description != "" {
+		return p.Endpoint.Description
+	}
+	return p.Endpoint.Target
+}
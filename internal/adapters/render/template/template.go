@@ -0,0 +1,193 @@
+// Package template executes a user supplied text/template against the
+// result, for partners that need their own layout.
+//
+// The template is executed with a Report and may use these functions besides
+// the text/template builtins:
+//
+//	statusSym P      OK, warning, skip or fail symbol of a Probe; for a bool
+//	                 (e.g. .Connected) the OK or fail symbol
+//	latency P        "12.34 ms" for a passed Probe or a float64 of ms, else "-"
+//	groups PROBES    the probes split into VPN, direct and proxy Groups,
+//	                 empty groups left out
+//	truncate N S     S cut to N characters, ending in "..." when cut
+//	pad N S          S padded with spaces to N characters
+//	join SEP LIST    the strings of LIST joined with SEP
+//	upper S, lower S
+//
+// Example:
+//
+//	{{statusSym .Connected}} {{.Summary}}
+//	{{range groups .Probes}}{{.Title}}
+//	{{range .Probes}}  {{statusSym .}} {{pad 30 .Name}} {{latency .}} {{truncate 60 .Error}}
+//	{{end}}{{end}}
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/azargarov/rsvpck/internal/adapters/render/text"
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// Template is a parsed user template, see ParseFile.
+type Template struct {
+	tmpl *template.Template
+}
+
+// Parse parses src, the functions are checked by name at this point and
+// bound to the render configuration when rendering.
+func Parse(name, src string) (*Template, error) {
+	t, err := template.New(name).Funcs(funcMap(nil)).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: t}, nil
+}
+
+func ParseFile(path string) (*Template, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(filepath.Base(path), string(b))
+}
+
+type Renderer struct {
+	template     *Template
+	conf         *text.RenderConfig
+	host         *domain.HostInfo
+	configSource string
+}
+
+type Option func(*Renderer)
+
+// WithRenderConfig selects the symbols of statusSym, ASCII or Unicode.
+func WithRenderConfig(c *text.RenderConfig) Option { return func(r *Renderer) { r.conf = c } }
+func WithHostInfo(h domain.HostInfo) Option        { return func(r *Renderer) { r.host = &h } }
+func WithConfigSource(s string) Option             { return func(r *Renderer) { r.configSource = s } }
+
+var _ domain.Renderer = (*Renderer)(nil)
+
+func NewRenderer(t *Template, opts ...Option) *Renderer {
+	r := &Renderer{template: t}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.conf == nil {
+		r.conf = text.NewRenderConfig()
+	}
+	return r
+}
+
+// Render writes nothing when the template fails, so a broken template does
+// not leave half a report behind.
+func (r *Renderer) Render(w io.Writer, result domain.ConnectivityResult) error {
+	t, err := r.template.tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := t.Funcs(funcMap(r.conf)).Execute(&buf, r.newReport(result)); err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func funcMap(conf *text.RenderConfig) template.FuncMap {
+	return template.FuncMap{
+		"statusSym": func(v any) (string, error) { return statusSym(conf, v) },
+		"latency":   latency,
+		"groups":    groups,
+		"truncate":  truncate,
+		"pad":       pad,
+		"join":      func(sep string, list []string) string { return strings.Join(list, sep) },
+		"upper":     strings.ToUpper,
+		"lower":     strings.ToLower,
+	}
+}
+
+func statusSym(conf *text.RenderConfig, v any) (string, error) {
+	switch v := v.(type) {
+	case Probe:
+		switch {
+		case v.Skipped:
+			return conf.SkipSym, nil
+		case v.Warning:
+			return conf.WarnSym, nil
+		case v.Passed:
+			return conf.OkSym, nil
+		default:
+			return conf.FailSym, nil
+		}
+	case bool:
+		if v {
+			return conf.OkSym, nil
+		}
+		return conf.FailSym, nil
+	default:
+		return "", fmt.Errorf("statusSym: want a Probe or bool, got %T", v)
+	}
+}
+
+func latency(v any) (string, error) {
+	switch v := v.(type) {
+	case Probe:
+		if !v.Passed && !v.Warning {
+			return "-", nil
+		}
+		return fmt.Sprintf("%.2f ms", v.LatencyMs), nil
+	case float64:
+		return fmt.Sprintf("%.2f ms", v), nil
+	default:
+		return "", fmt.Errorf("latency: want a Probe or float64, got %T", v)
+	}
+}
+
+// groups keeps the section order of the built-in renderers.
+func groups(probes []Probe) []Group {
+	out := []Group{
+		{Name: domain.GroupVPN.String(), Title: domain.GroupVPN.Title()},
+		{Name: domain.GroupDirect.String(), Title: domain.GroupDirect.Title()},
+		{Name: domain.GroupProxy.String(), Title: domain.GroupProxy.Title()},
+	}
+	for _, p := range probes {
+		for i := range out {
+			if out[i].Name == p.Group {
+				out[i].Probes = append(out[i].Probes, p)
+			}
+		}
+	}
+	nonEmpty := out[:0]
+	for _, g := range out {
+		if len(g.Probes) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+	return nonEmpty
+}
+
+func truncate(n int, s string) string {
+	if n < 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	rn := []rune(s)
+	if n <= 3 {
+		return string(rn[:n])
+	}
+	return string(rn[:n-3]) + "..."
+}
+
+func pad(n int, s string) string {
+	if c := utf8.RuneCountInString(s); c < n {
+		return s + strings.Repeat(" ", n-c)
+	}
+	return s
+}
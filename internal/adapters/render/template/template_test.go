@@ -0,0 +1,135 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/adapters/render/text"
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func TestStatusSym(t *testing.T) {
+	conf := text.NewRenderConfig(text.WithForceASCII(true))
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"passed", Probe{Passed: true}, "OK"},
+		{"warning", Probe{Passed: true, Warning: true}, "!"},
+		{"skipped", Probe{Skipped: true}, "-"},
+		{"failed", Probe{Failed: true}, "X"},
+		{"connected", true, "OK"},
+		{"not connected", false, "X"},
+	}
+	for _, tt := range tests {
+		got, err := statusSym(conf, tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("statusSym(%s) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+	if _, err := statusSym(conf, "pass"); err == nil {
+		t.Error("statusSym of a string must be an error")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		n    int
+		s    string
+		want string
+	}{
+		{10, "short", "short"},
+		{5, "exact", "exact"},
+		{8, "connection refused", "conne..."},
+		{4, "timeout", "t..."},
+		{3, "timeout", "tim"},
+		{0, "timeout", ""},
+		{-1, "timeout", "timeout"},
+		{5, "", ""},
+		{4, "äöüßé", "ä..."},
+		{2, "日本語", "日本"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.n, tt.s); got != tt.want {
+			t.Errorf("truncate(%d, %q) = %q, want %q", tt.n, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPad(t *testing.T) {
+	tests := []struct {
+		n    int
+		s    string
+		want string
+	}{
+		{6, "dns", "dns   "},
+		{3, "dns", "dns"},
+		{2, "dns", "dns"},
+		{0, "dns", "dns"},
+		{-2, "dns", "dns"},
+		{3, "", "   "},
+		{4, "äö", "äö  "},
+	}
+	for _, tt := range tests {
+		if got := pad(tt.n, tt.s); got != tt.want {
+			t.Errorf("pad(%d, %q) = %q, want %q", tt.n, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	result := domain.ConnectivityResult{
+		Mode:        domain.ModeDirect,
+		IsConnected: true,
+		Summary:     "direct",
+		Probes: []domain.Probe{
+			domain.NewSuccessfulProbe(domain.MustNewTCPEndpoint("example.com:443", domain.EndpointTypePublic, "web"), 12.345),
+			domain.NewFailedProbe(domain.MustNewICMPEndpoint("10.0.0.1", domain.EndpointTypeVPN, ""), domain.StatusTimeout, errors.New("no answer")),
+		},
+	}
+	tmpl, err := Parse("test", `{{statusSym .Connected}} {{.Summary}}
+{{range groups .Probes}}{{.Name}}:{{range .Probes}} [{{statusSym .}} {{pad 9 .Name}}|{{latency .}}]{{end}}
+{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	conf := text.NewRenderConfig(text.WithForceASCII(true))
+	if err := NewRenderer(tmpl, WithRenderConfig(conf)).Render(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	want := "OK direct\nvpn: [X 10.0.0.1 |-]\ndirect: [OK web      |12.35 ms]\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRenderFailingTemplateWritesNothing(t *testing.T) {
+	result := domain.ConnectivityResult{Summary: "direct"}
+	for _, src := range []string{
+		`header {{.Summary}} {{statusSym .Summary}}`,
+		`{{.Summary}} {{.NoSuchField}}`,
+		`{{.Summary}} {{truncate "x" .Summary}}`,
+	} {
+		tmpl, err := Parse("test", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := NewRenderer(tmpl).Render(&buf, result); err == nil {
+			t.Errorf("Render(%q) = nil error, want the template error", src)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Render(%q) wrote %q, want nothing", src, buf.String())
+		}
+	}
+}
+
+func TestParseRejectsUnknownFunction(t *testing.T) {
+	if _, err := Parse("test", `{{nosuchfunc .Summary}}`); err == nil {
+		t.Error("Parse with an unknown function = nil error")
+	}
+}
@@ -16,9 +16,9 @@ type RenderConfig struct {
 	ForceUnicode *bool // nil = auto, true/false = force
 	Unicode bool
 	Color   bool
-	OkSym, FailSym string
+	OkSym, FailSym, WarnSym, SkipSym string
 	Divider1, Divider2 string
-	Green, Red colorFunc
+	Green, Red, Yellow colorFunc
 	TableSymbols *tw.SymbolCustom
 }
 
@@ -62,7 +62,8 @@ func (c *RenderConfig) initTheme() {
 		color.NoColor = false // enable ANSI
 		c.Green = color.New(color.FgGreen).SprintFunc()
 		c.Red   = color.New(color.FgRed).SprintFunc()
-		c.OkSym, c.FailSym = c.Green("✓"), c.Red("✗")
+		c.Yellow = color.New(color.FgYellow).SprintFunc()
+		c.OkSym, c.FailSym, c.WarnSym, c.SkipSym = c.Green("✓"), c.Red("✗"), c.Yellow("!"), "–"
 		c.Divider1, c.Divider2 = "═", "─"
 		c.TableSymbols = tw.NewSymbolCustom("Box").
 			WithRow("─").WithColumn("│").
@@ -71,10 +72,11 @@ func (c *RenderConfig) initTheme() {
 			WithBottomLeft("└").WithBottomMid("┴").WithBottomRight("┘")
 	} else {
 		color.NoColor = true // disable ANSI
-		c.OkSym, c.FailSym = "OK", "X"
+		c.OkSym, c.FailSym, c.WarnSym, c.SkipSym = "OK", "X", "!", "-"
 		c.Divider1, c.Divider2 = "=", "-"
 		c.Green = func(a ...any) string { return fmt.Sprint(a...) }
 		c.Red   = func(a ...any) string { return fmt.Sprint(a...) }
+		c.Yellow = func(a ...any) string { return fmt.Sprint(a...) }
 		c.TableSymbols = tw.NewSymbolCustom("ASCII").
 			WithRow("-").WithColumn("|").
 			WithTopLeft("+").WithTopMid("+").WithTopRight("+").
@@ -17,9 +17,43 @@ func printSummary(w io.Writer, result domain.ConnectivityResult, conf *RenderCon
 	mode := modeString(result.Mode)
 	fmt.Fprintln(w, "")
 	fmt.Fprintf(w, "%s > Mode: %s\n", status, mode)
+	if issues := result.IssueSummary(); issues != "" {
+		fmt.Fprintf(w, "Issues: %s\n", issues)
+	}
 	fmt.Fprintln(w, "")
 }
 
+// statusSymbol returns the colored symbol for the probe outcome.
+func statusSymbol(p domain.Probe, conf *RenderConfig) string {
+	switch {
+	case p.IsSkipped():
+		return conf.SkipSym
+	case p.IsWarning():
+		return conf.WarnSym
+	case p.IsSuccessful():
+		return conf.OkSym
+	default:
+		return conf.FailSym
+	}
+}
+
+// statusWord is a short status name for table cells.
+// Failures keep their classification, e.g. "Timeout" or "DNS Failure".
+func statusWord(s domain.Status) string {
+	switch s {
+	case domain.StatusPass:
+		return "Pass"
+	case domain.StatusWarning:
+		return "Warn"
+	case domain.StatusSkipped:
+		return "Skip"
+	case domain.StatusFail:
+		return "Fail"
+	default:
+		return s.String()
+	}
+}
+
 func modeString(mode domain.ConnectivityMode) string {
 	switch mode {
 	case domain.ModeDirect:
@@ -63,3 +97,89 @@ func truncateErrorUTF8(msg string, max int) string {
 	}
 	return string(rn[:max-3]) + "..."
 }
+
+func severityLabel(sev domain.Severity, conf *RenderConfig) string {
+	label := strings.ToUpper(sev.String())
+	switch sev {
+	case domain.SeverityCritical:
+		return conf.Red(label)
+	case domain.SeverityWarning:
+		return conf.Yellow(label)
+	default:
+		return label
+	}
+}
+
+func probeName(p domain.Probe) string {
+	if p.Endpoint.Description != "" {
+		return p.Endpoint.Description
+	}
+	return p.Endpoint.Target
+}
+
+func evidenceList(f domain.Finding) string {
+	names := make([]string, 0, len(f.Evidence))
+	for _, p := range f.Evidence {
+		names = append(names, probeName(p))
+	}
+	return strings.Join(names, ", ")
+}
+
+// echoSummary describes native ICMP results, e.g. "1/1 replies, ttl 64".
+// It is empty for probes without per-packet data.
+func echoSummary(p domain.Probe) string {
+	if p.Echo == nil {
+		return ""
+	}
+	str := fmt.Sprintf("%d/%d replies", p.Echo.Received, p.Echo.Sent)
+	if n := len(p.Echo.Replies); n > 0 && p.Echo.Replies[n-1].TTL > 0 {
+		str += fmt.Sprintf(", ttl %d", p.Echo.Replies[n-1].TTL)
+	}
+	return str
+}
+
+// statsSummary describes multi-sample results,
+// e.g. "n=5 min/avg/max 10.1/12.3/15.0 ms jitter 1.2 ms loss 0%".
+func statsSummary(p domain.Probe) string {
+	st := p.Stats
+	if st == nil {
+		return ""
+	}
+	if st.Received == 0 {
+		return fmt.Sprintf("n=%d loss %.0f%%", st.Samples, st.LossPercent)
+	}
+	return fmt.Sprintf("n=%d min/avg/max %.1f/%.1f/%.1f ms jitter %.1f ms loss %.0f%%",
+		st.Samples, st.MinMs, st.AvgMs, st.MaxMs, st.JitterMs, st.LossPercent)
+}
+
+// probeSummary joins the echo and sampling summaries that apply to p.
+func probeSummary(p domain.Probe) string {
+	var parts []string
+	for _, s := range []string{statsSummary(p), echoSummary(p), retrySummary(p)} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// retrySummary tells a pass on retry or a failure after several attempts
+// apart from a first attempt result.
+func retrySummary(p domain.Probe) string {
+	switch {
+	case p.PassedOnRetry() > 0:
+		return fmt.Sprintf("passed on retry %d", p.PassedOnRetry())
+	case !p.IsSuccessful() && len(p.Attempts) > 1:
+		return fmt.Sprintf("after %d attempts", len(p.Attempts))
+	}
+	return ""
+}
+
+// errorDetails is the truncated probe error with the retry outcome appended.
+func errorDetails(p domain.Probe) string {
+	msg := truncateError(p.Error, maxCharPerError)
+	if retry := retrySummary(p); retry != "" {
+		msg += " (" + retry + ")"
+	}
+	return msg
+}
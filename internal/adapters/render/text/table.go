@@ -38,6 +38,10 @@ func (tr *TableRenderer) Render(w io.Writer, result domain.ConnectivityResult) e
 	if len(proxy) > 0 {
 		tr.renderProbeTable(w, proxy, "Internet via Proxy")
 	}
+
+	if len(result.Findings) > 0 {
+		tr.renderFindingsTable(w, result.Findings)
+	}
 	
 	printSummary(w, result, tr.conf)
 	
@@ -45,16 +49,7 @@ func (tr *TableRenderer) Render(w io.Writer, result domain.ConnectivityResult) e
 }
 
 func (tr *TableRenderer) groupProbes(probes []domain.Probe) (vpn, direct, proxy []domain.Probe) {
-	for _, p := range probes {
-		if p.Endpoint.Type == domain.EndpointTypeVPN {
-			vpn = append(vpn, p)
-		} else if p.Endpoint.Proxy.MustUseProxy() {
-			proxy = append(proxy, p)
-		} else {
-			direct = append(direct, p)
-		}
-	}
-	return
+	return domain.GroupProbes(probes)
 }
 
 func (tr *TableRenderer) renderProbeTable(w io.Writer, probes []domain.Probe, name string) {
@@ -74,19 +69,16 @@ func (tr *TableRenderer) renderProbeTable(w io.Writer, probes []domain.Probe, na
 			desc = fmt.Sprintf("%s (%s)", p.Endpoint.Target, p.Endpoint.TargetType.String())
 		}
 
-		statusStr := tr.conf.FailSym + " Fail"
-		if p.IsSuccessful() {
-			statusStr = tr.conf.OkSym + " Pass"
-		}
+		statusStr := statusSymbol(p, tr.conf) + " " + statusWord(p.Status)
 
 		latencyStr := "-"
 		if p.IsSuccessful() {
 			latencyStr = fmt.Sprintf("%.2f ms", p.LatencyMs)
 		}
 
-		details := ""
-		if !p.IsSuccessful() && p.Error != "" {
-			details = truncateError(p.Error, maxCharPerError)
+		details := probeSummary(p)
+		if p.Error != "" {
+			details = errorDetails(p)
 		}
 
 		table.Append([]string{desc, statusStr, latencyStr, details})
@@ -95,6 +87,28 @@ func (tr *TableRenderer) renderProbeTable(w io.Writer, probes []domain.Probe, na
 	table.Render()
 }
 
+func (tr *TableRenderer) renderFindingsTable(w io.Writer, findings []domain.Finding) {
+	table := tablewriter.NewTable(w,
+		tablewriter.WithAlignment([]tw.Align{tw.AlignRight, tw.AlignLeft, tw.AlignLeft, tw.AlignLeft}),
+		tablewriter.WithRowAutoWrap(tw.WrapNormal),
+		tablewriter.WithHeaderAutoWrap(tw.WrapTruncate),
+		tablewriter.WithMaxWidth(maxTableWidth),
+		tablewriter.WithRenderer(renderer.NewBlueprint(tw.Rendition{Symbols: tr.conf.TableSymbols})),
+	)
+
+	table.Header([]string{"#", "Diagnosis", "Severity", "Remediation"})
+
+	for i, f := range findings {
+		remediation := f.Remediation
+		if len(f.Evidence) > 0 {
+			remediation += "\nEvidence: " + evidenceList(f)
+		}
+		table.Append([]string{fmt.Sprint(i + 1), f.Title, severityLabel(f.Severity, tr.conf), remediation})
+	}
+
+	table.Render()
+}
+
 func getTableBorders() *tw.SymbolCustom {
 
 	nature := tw.NewSymbolCustom("Nature").
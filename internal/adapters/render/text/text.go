@@ -37,22 +37,31 @@ func (r *TextRenderer) Render(w io.Writer, result domain.ConnectivityResult) err
 		fmt.Fprintln(w)
 	}
 	
+	if len(result.Findings) > 0 {
+		fmt.Fprintln(w, "Diagnosis:")
+		r.renderFindings(w, result.Findings)
+		fmt.Fprintln(w)
+	}
+
 	printSummary(w, result, r.conf)
 
 	return nil
 }
 
-func (r *TextRenderer) groupProbes(probes []domain.Probe) (vpn, direct, proxy []domain.Probe) {
-	for _, p := range probes {
-		if p.Endpoint.Type == domain.EndpointTypeVPN {
-			vpn = append(vpn, p)
-		} else if p.Endpoint.Proxy.MustUseProxy(){
-			proxy = append(proxy, p)
-		} else {
-			direct = append(direct, p)
+func (r *TextRenderer) renderFindings(w io.Writer, findings []domain.Finding) {
+	for i, f := range findings {
+		fmt.Fprintf(w, "\t%d. [%s] %s\n", i+1, severityLabel(f.Severity, r.conf), f.Title)
+		if f.Remediation != "" {
+			fmt.Fprintf(w, "\t   %s\n", f.Remediation)
+		}
+		if len(f.Evidence) > 0 {
+			fmt.Fprintf(w, "\t   Evidence: %s\n", evidenceList(f))
 		}
 	}
-	return
+}
+
+func (r *TextRenderer) groupProbes(probes []domain.Probe) (vpn, direct, proxy []domain.Probe) {
+	return domain.GroupProbes(probes)
 }
 
 func (r *TextRenderer) renderProbeList(w io.Writer, probes []domain.Probe) {
@@ -64,21 +73,24 @@ func (r *TextRenderer) renderProbeList(w io.Writer, probes []domain.Probe) {
 	})
 
 	for _, p := range probes {
-		statusIcon := r.conf.FailSym
-		if p.IsSuccessful() {
-			statusIcon = r.conf.OkSym
-		}
+		statusIcon := statusSymbol(p, r.conf)
 
 		desc := p.Endpoint.Description
 		if desc == "" {
 			desc = p.Endpoint.Target
 		}
 
-		if p.IsSuccessful() {
-			fmt.Fprintf(w, "\t%s %-40s [%.2f ms]\n", statusIcon, desc, p.LatencyMs)
+		if p.IsWarning() {
+			fmt.Fprintf(w, "\t%s %-40s [%.2f ms] %s\n", statusIcon, desc, p.LatencyMs, errorDetails(p))
+		} else if p.IsSuccessful() {
+			fmt.Fprintf(w, "\t%s %-40s [%.2f ms]", statusIcon, desc, p.LatencyMs)
+			if summary := probeSummary(p); summary != "" {
+				fmt.Fprintf(w, " %s", summary)
+			}
+			fmt.Fprintln(w)
 		} else {
-			errorMsg := truncateError(p.Error, maxCharPerError)
-			fmt.Fprintf(w, "\t%s %-40s %s\n", statusIcon, desc, errorMsg)
+			errorMsg := errorDetails(p)
+			fmt.Fprintf(w, "\t%s %-40s [%s] %s\n", statusIcon, desc, statusWord(p.Status), errorMsg)
 		}
 	}
 }
@@ -0,0 +1,87 @@
+package text
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/renderer"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+const watchTimeLayout = "2006-01-02 15:04:05"
+
+// PrintWatchStart prints the state of the first watch run, later runs only
+// print their transitions.
+func PrintWatchStart(w io.Writer, result domain.ConnectivityResult, conf *RenderConfig) {
+	ran := len(result.Probes) - len(result.SkippedProbes())
+	fmt.Fprintf(w, "%s  mode %s, %d/%d endpoints reachable\n",
+		result.Timestamp.Format(watchTimeLayout), modeString(result.Mode), len(result.SuccessfulProbes()), ran)
+	for _, p := range result.FailedProbes() {
+		fmt.Fprintf(w, "%s  %s %s [%s] %s\n", result.Timestamp.Format(watchTimeLayout),
+			conf.FailSym, probeName(p), statusWord(p.Status), truncateError(p.Error, maxCharPerError))
+	}
+}
+
+// PrintTransitions prints one timestamped line per transition.
+func PrintTransitions(w io.Writer, transitions []domain.Transition, conf *RenderConfig) {
+	for _, t := range transitions {
+		ts := t.Time.Format(watchTimeLayout)
+		if t.Kind == domain.TransitionMode {
+			line := fmt.Sprintf("mode %s -> %s", modeString(t.FromMode), modeString(t.ToMode))
+			if t.ToMode == domain.ModeNone {
+				line = conf.Red(line)
+			}
+			fmt.Fprintf(w, "%s  %s\n", ts, line)
+			continue
+		}
+
+		sym := conf.OkSym
+		if !t.Probe.IsSuccessful() {
+			sym = conf.FailSym
+		}
+		fmt.Fprintf(w, "%s  %s %s: %s -> %s", ts, sym, probeName(t.Probe), statusWord(t.From), statusWord(t.To))
+		if t.Probe.Error != "" {
+			fmt.Fprintf(w, " (%s)", truncateError(t.Probe.Error, maxCharPerError))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// RenderUptime prints the per endpoint uptime collected by a watch session.
+func RenderUptime(w io.Writer, elapsed time.Duration, runs, connected int, uptime []domain.EndpointUptime, conf *RenderConfig) {
+	fmt.Fprintf(w, "\nWATCH SUMMARY\n%s\n", strings.Repeat(conf.Divider2, minDevLen))
+	fmt.Fprintf(w, "Watched for %s, %d runs, connected in %d (%.1f%%)\n",
+		elapsed.Round(time.Second), runs, connected, percent(connected, runs))
+
+	table := tablewriter.NewTable(w,
+		tablewriter.WithAlignment([]tw.Align{tw.AlignLeft, tw.AlignRight, tw.AlignRight}),
+		tablewriter.WithRowAutoWrap(tw.WrapNormal),
+		tablewriter.WithHeaderAutoWrap(tw.WrapTruncate),
+		tablewriter.WithMaxWidth(maxTableWidth),
+		tablewriter.WithRenderer(renderer.NewBlueprint(tw.Rendition{Symbols: conf.TableSymbols})),
+	)
+	table.Header([]string{"Endpoint", "Passed", "Uptime"})
+	for _, u := range uptime {
+		uptimeStr := "-"
+		if u.Runs > 0 {
+			uptimeStr = fmt.Sprintf("%.1f%%", u.Percent())
+		}
+		table.Append([]string{
+			probeName(domain.Probe{Endpoint: u.Endpoint}),
+			fmt.Sprintf("%d/%d", u.Passed, u.Runs),
+			uptimeStr,
+		})
+	}
+	table.Render()
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
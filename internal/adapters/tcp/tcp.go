@@ -10,11 +10,10 @@ import (
 	"time"
 )
 
-const localTimeOut = 10 * time.Second
-
 type Checker struct{}
 
-// CheckWithContext executes TCP-connect to the target  "host:port"
+// CheckWithContext executes TCP-connect to the target  "host:port",
+// the connect is bounded by the deadline of ctx.
 func (c Checker) CheckWithContext(ctx context.Context, ep domain.Endpoint) domain.Probe {
 	if _, _, err := net.SplitHostPort(ep.Target); err != nil {
 		return domain.NewFailedProbe(
@@ -27,7 +26,6 @@ func (c Checker) CheckWithContext(ctx context.Context, ep domain.Endpoint) domai
 	start := time.Now()
 
 	dialer := &net.Dialer{
-		Timeout:   localTimeOut,
 		KeepAlive: 0,
 	}
 
@@ -58,6 +56,11 @@ func mapErrorToStatus(err, contextErr error) domain.Status {
 		}
 	}
 
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.Timeout() {
+		return domain.StatusDNSFailure
+	}
+
 	var netErr net.Error
 	if errors.As(err, &netErr) {
 		if netErr.Timeout() {
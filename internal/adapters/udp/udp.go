@@ -0,0 +1,114 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// noReplyWindow is how long a probe without an expected response waits for
+// an ICMP port unreachable before it reports the port as reachable. Shorter
+// probe timeouts cut it to noReplyShare of the time left, so the wait ends
+// well before the deadline and a silent port is not reported as a timeout.
+const (
+	noReplyWindow = time.Second
+	noReplyShare  = 0.75
+)
+
+const maxDatagramSize = 64 * 1024
+
+type Checker struct{}
+
+// CheckUDPWithContext sends the endpoint payload to "host:port" and, when
+// an expected pattern is set, waits for a response matching it. UDP has no
+// handshake, so without a pattern a silent port counts as reachable and only
+// an ICMP port unreachable fails the probe.
+func (c Checker) CheckUDPWithContext(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	if _, _, err := net.SplitHostPort(ep.Target); err != nil {
+		return domain.NewFailedProbe(
+			ep,
+			domain.StatusInvalid,
+			errors.New("invalid target format, expected host:port"),
+		)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", ep.Target)
+	if err != nil {
+		return domain.NewFailedProbe(ep, mapErrorToStatus(err, ctx.Err()), err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	start := time.Now()
+	if _, err := conn.Write(ep.UDP.Payload); err != nil {
+		return domain.NewFailedProbe(ep, mapErrorToStatus(err, ctx.Err()), err)
+	}
+
+	readDeadline, ok := ctx.Deadline()
+	if !ok {
+		readDeadline = start.Add(ep.ProbeTimeout())
+	}
+	if ep.UDP.Expect == nil {
+		window := min(noReplyWindow, time.Duration(float64(time.Until(readDeadline))*noReplyShare))
+		readDeadline = time.Now().Add(window)
+	}
+	conn.SetReadDeadline(readDeadline)
+
+	buf := make([]byte, maxDatagramSize)
+	n, err := conn.Read(buf)
+	latencyMs := time.Since(start).Seconds() * 1000
+
+	if err != nil {
+		var netErr net.Error
+		if ep.UDP.Expect == nil && ctx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout() {
+			// no reply and no port unreachable, the datagram was not rejected;
+			// nothing came back, so there is no round trip to report
+			return domain.NewSuccessfulProbe(ep, 0)
+		}
+		if ep.UDP.Expect != nil && isTimeout(err, ctx.Err()) {
+			err = fmt.Errorf("no response from %s: %w", ep.Target, err)
+		}
+		return domain.NewFailedProbe(ep, mapErrorToStatus(err, ctx.Err()), err)
+	}
+
+	if ep.UDP.Expect != nil && !ep.UDP.Expect.Match(buf[:n]) {
+		return domain.NewFailedProbe(ep, domain.StatusFail,
+			fmt.Errorf("response from %s does not match %q: %q", ep.Target, ep.UDP.Expect, truncate(buf[:n], 64)))
+	}
+	return domain.NewSuccessfulProbe(ep, latencyMs)
+}
+
+func mapErrorToStatus(err, contextErr error) domain.Status {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && !dnsErr.Timeout():
+		return domain.StatusDNSFailure
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return domain.StatusConnectionRefused
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH), isTimeout(err, contextErr):
+		return domain.StatusTimeout
+	}
+	return domain.StatusFail
+}
+
+func isTimeout(err, contextErr error) bool {
+	if contextErr != nil {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func truncate(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[:n]
+	}
+	return b
+}
@@ -0,0 +1,107 @@
+package udp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// listen starts a UDP server on loopback that answers every datagram with
+// reply(datagram), or stays silent when reply is nil.
+func listen(t *testing.T, reply func([]byte) []byte) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply != nil {
+				pc.WriteTo(reply(buf[:n]), addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func endpoint(t *testing.T, target, expect string) domain.Endpoint {
+	t.Helper()
+	ep, err := domain.NewUDPEndpoint(target, domain.EndpointTypePublic, []byte("ping"), expect, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ep
+}
+
+func check(ep domain.Endpoint, timeout time.Duration) domain.Probe {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return Checker{}.CheckUDPWithContext(ctx, ep)
+}
+
+// A silent port without an expected response passes without a made up
+// round trip time, also with a timeout shorter than noReplyWindow.
+func TestSilentPortWithoutExpect(t *testing.T) {
+	ep := endpoint(t, listen(t, nil), "")
+	for _, tt := range []struct {
+		timeout time.Duration
+		runs    int
+	}{
+		{2 * time.Second, 1},
+		{200 * time.Millisecond, 5},
+		{20 * time.Millisecond, 20},
+	} {
+		timeout := tt.timeout
+		for range tt.runs {
+			p := check(ep, timeout)
+			if p.Status != domain.StatusPass {
+				t.Fatalf("timeout %s: status = %s (%s), want pass", timeout, p.Status, p.Error)
+			}
+			if p.LatencyMs != 0 {
+				t.Fatalf("timeout %s: latency = %v ms, want 0 for a port that did not answer", timeout, p.LatencyMs)
+			}
+		}
+	}
+}
+
+func TestExpectedResponse(t *testing.T) {
+	echo := listen(t, func(b []byte) []byte { return append([]byte("pong "), b...) })
+
+	p := check(endpoint(t, echo, "^pong"), time.Second)
+	if p.Status != domain.StatusPass || p.LatencyMs <= 0 {
+		t.Errorf("matching reply: status %s, latency %v, want pass with a round trip time", p.Status, p.LatencyMs)
+	}
+
+	p = check(endpoint(t, echo, "^PONG"), time.Second)
+	if p.Status != domain.StatusFail {
+		t.Errorf("mismatching reply: status %s, want fail", p.Status)
+	}
+
+	p = check(endpoint(t, listen(t, nil), "^pong"), 200*time.Millisecond)
+	if p.Status != domain.StatusTimeout {
+		t.Errorf("no reply: status %s (%s), want timeout", p.Status, p.Error)
+	}
+}
+
+func TestClosedPortIsRefused(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := pc.LocalAddr().String()
+	pc.Close()
+
+	p := check(endpoint(t, target, ""), time.Second)
+	if p.Status != domain.StatusConnectionRefused {
+		t.Errorf("status = %s (%s), want connection refused", p.Status, p.Error)
+	}
+}
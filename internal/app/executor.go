@@ -2,6 +2,11 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/azargarov/rsvpck/internal/domain"
 )
 
@@ -10,7 +15,35 @@ type Executor struct {
 	dnsChecker  domain.DNSChecker
 	httpChecker domain.HTTPChecker
 	icmpChecker domain.ICMPChecker
+	tlsChecker  domain.TLSChecker
+	udpChecker  domain.UDPChecker
 	policy      domain.ExecutionPolicy
+	concurrency int
+}
+
+const skipReasonNoICMP = "no ICMP endpoint of this group answered"
+
+type ExecutorOption func(*Executor)
+
+// WithConcurrency sets how many probes of one group may run at the same time.
+// Values below 1 mean sequential execution.
+func WithConcurrency(n int) ExecutorOption {
+	return func(e *Executor) {
+		if n < 1 {
+			n = 1
+		}
+		e.concurrency = n
+	}
+}
+
+// WithTLSChecker enables endpoints of kind tls.
+func WithTLSChecker(c domain.TLSChecker) ExecutorOption {
+	return func(e *Executor) { e.tlsChecker = c }
+}
+
+// WithUDPChecker enables endpoints of kind udp.
+func WithUDPChecker(c domain.UDPChecker) ExecutorOption {
+	return func(e *Executor) { e.udpChecker = c }
 }
 
 func NewExecutor(
@@ -19,14 +52,20 @@ func NewExecutor(
 	httpChecker domain.HTTPChecker,
 	icmpChecker domain.ICMPChecker,
 	policy domain.ExecutionPolicy,
+	opts ...ExecutorOption,
 ) *Executor {
-	return &Executor{
+	e := &Executor{
 		tcpChecker:  tcpChecker,
 		dnsChecker:  dnsChecker,
 		httpChecker: httpChecker,
 		icmpChecker: icmpChecker,
 		policy:      policy,
+		concurrency: 1,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 func (e *Executor) Run(ctx context.Context, config domain.NetTestConfig) domain.ConnectivityResult {
@@ -46,6 +85,9 @@ func (e *Executor) Run(ctx context.Context, config domain.NetTestConfig) domain.
 	return domain.AnalyzeConnectivity(probes, config)
 }
 
+// runOptimizedChecks probes ICMP endpoints first and only continues with the
+// rest when at least one of them answered. Endpoints that are not probed are
+// returned as skipped so they still show up in the result.
 func (e *Executor) runOptimizedChecks(ctx context.Context, endpoints []domain.Endpoint) []domain.Probe {
 	var probes []domain.Probe
 	var ipReachable bool
@@ -71,32 +113,166 @@ func (e *Executor) runOptimizedChecks(ctx context.Context, endpoints []domain.En
 	if ipReachable {
 		otherProbes := e.runEndpointCheck(ctx, otherEndpoints)
 		probes = append(probes, otherProbes...)
+		return probes
 	}
 
+	for _, ep := range otherEndpoints {
+		probes = append(probes, domain.NewSkippedProbe(ep, skipReasonNoICMP))
+	}
 	return probes
 }
 
+// runEndpointCheck probes all endpoints using up to e.concurrency workers.
+// Every worker writes only to its own slot, so the result keeps the order
+// of endpoints regardless of completion order.
 func (e Executor) runEndpointCheck(ctx context.Context, endpoints []domain.Endpoint) []domain.Probe {
-	var probes []domain.Probe
-	for _, ep := range endpoints {
-		var probe domain.Probe
-
-		switch ep.GetTargetType() {
-		case domain.TargetTypeICMP:
-			probe = e.icmpChecker.CheckPingWithContext(ctx, ep)
-		case domain.TargetTypeTCP:
-			probe = e.tcpChecker.CheckWithContext(ctx, ep)
-		case domain.TargetTypeHTTP:
-			if ep.Proxy.MustUseProxy(){
-				probe = e.httpChecker.CheckViaProxyWithContext(ctx, ep, ep.Proxy.URL())
-			} else {
-				probe = e.httpChecker.CheckWithContext(ctx, ep)
-			}
-		case domain.TargetTypeDNS:
-			probe = e.dnsChecker.CheckWithContext(ctx, ep)
+	probes := make([]domain.Probe, len(endpoints))
+
+	workers := min(e.concurrency, len(endpoints))
+	if workers <= 1 {
+		for i, ep := range endpoints {
+			probes[i] = e.checkEndpoint(ctx, ep)
 		}
+		return probes
+	}
 
-		probes = append(probes, probe)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				probes[i] = e.checkEndpoint(ctx, endpoints[i])
+			}
+		}()
+	}
+	for i := range endpoints {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
+
 	return probes
 }
+
+// checkEndpoint probes ep and retries transient failures according to
+// ep.Retry, every attempt is recorded on the returned probe.
+func (e Executor) checkEndpoint(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	if !ep.Retry.Enabled() {
+		return e.checkSampled(ctx, ep)
+	}
+
+	var (
+		p        domain.Probe
+		attempts []domain.Attempt
+	)
+	for n := 1; n <= ep.Retry.MaxAttempts; n++ {
+		if n > 1 && !sleepCtx(ctx, ep.Retry.Delay(n)) {
+			break
+		}
+		p = e.checkSampled(ctx, ep)
+		attempts = append(attempts, domain.NewAttempt(p))
+		if !ep.Retry.Retryable(p.Status) {
+			break
+		}
+	}
+	p.Attempts = attempts
+	return p
+}
+
+// checkSampled probes ep once, or ep.Sampling.Count times for multi-sample
+// endpoints, see sample.
+func (e Executor) checkSampled(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	if ep.Sampling.IsMulti() {
+		return e.sample(ctx, ep)
+	}
+	return e.checkOnce(ctx, ep)
+}
+
+// sample runs the endpoint check ep.Sampling.Count times and folds the samples
+// into one probe: the last successful sample with the average latency and
+// ProbeStats attached, or the last failure when nothing succeeded.
+// A successful probe becomes a warning when loss or jitter exceed the limits.
+func (e Executor) sample(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	var (
+		last, lastOK domain.Probe
+		latencies    []float64
+		samples      int
+	)
+	for i := 0; i < ep.Sampling.Count; i++ {
+		if i > 0 && !sleepCtx(ctx, ep.Sampling.Interval) {
+			break
+		}
+		last = e.checkOnce(ctx, ep)
+		samples++
+		if last.IsSuccessful() {
+			lastOK = last
+			latencies = append(latencies, last.LatencyMs)
+		}
+	}
+
+	stats := domain.NewProbeStats(samples, latencies)
+	if len(latencies) == 0 {
+		last.Stats = &stats
+		return last
+	}
+
+	p := lastOK
+	p.Stats = &stats
+	p.LatencyMs = stats.AvgMs
+	switch {
+	case stats.LossPercent > ep.Sampling.MaxLossPercent:
+		p.MarkWarning(stats.AvgMs, fmt.Errorf("%.0f%% loss (%d/%d samples), limit %.0f%%",
+			stats.LossPercent, stats.Samples-stats.Received, stats.Samples, ep.Sampling.MaxLossPercent))
+	case ep.Sampling.MaxJitterMs > 0 && stats.JitterMs > ep.Sampling.MaxJitterMs:
+		p.MarkWarning(stats.AvgMs, fmt.Errorf("jitter %.2f ms, limit %.2f ms", stats.JitterMs, ep.Sampling.MaxJitterMs))
+	}
+	return p
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// checkOnce runs a single check bounded by the endpoint timeout, so a stuck
+// handshake cannot consume the run deadline of the probes after it.
+func (e Executor) checkOnce(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	ctx, cancel := context.WithTimeout(ctx, ep.ProbeTimeout())
+	defer cancel()
+
+	switch ep.GetTargetType() {
+	case domain.TargetTypeICMP:
+		return e.icmpChecker.CheckPingWithContext(ctx, ep)
+	case domain.TargetTypeTCP:
+		return e.tcpChecker.CheckWithContext(ctx, ep)
+	case domain.TargetTypeHTTP:
+		if ep.Proxy.MustUseProxy(){
+			return e.httpChecker.CheckViaProxyWithContext(ctx, ep, ep.Proxy.URL())
+		}
+		return e.httpChecker.CheckWithContext(ctx, ep)
+	case domain.TargetTypeDNS:
+		return e.dnsChecker.CheckWithContext(ctx, ep)
+	case domain.TargetTypeTLS:
+		if e.tlsChecker == nil {
+			return domain.NewFailedProbe(ep, domain.StatusInvalid, errors.New("no TLS checker configured"))
+		}
+		return e.tlsChecker.CheckTLSWithContext(ctx, ep)
+	case domain.TargetTypeUDP:
+		if e.udpChecker == nil {
+			return domain.NewFailedProbe(ep, domain.StatusInvalid, errors.New("no UDP checker configured"))
+		}
+		return e.udpChecker.CheckUDPWithContext(ctx, ep)
+	}
+	return domain.Probe{Endpoint: ep}
+}
@@ -0,0 +1,268 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// fakeChecker answers TCP and ICMP checks from a table and records the order
+// the checks were started in. A target in script answers its nth check with
+// the nth latency, a negative latency is a lost sample.
+type fakeChecker struct {
+	delay  func(target string) time.Duration
+	fail   map[string]bool
+	script map[string][]float64
+
+	mu          sync.Mutex
+	calls       []string
+	inFlight    int
+	maxInFlight int
+}
+
+func (f *fakeChecker) check(ep domain.Endpoint) domain.Probe {
+	f.mu.Lock()
+	n := 0
+	for _, c := range f.calls {
+		if c == ep.Target {
+			n++
+		}
+	}
+	f.calls = append(f.calls, ep.Target)
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if f.delay != nil {
+		time.Sleep(f.delay(ep.Target))
+	}
+	latency := 1.0
+	if script, ok := f.script[ep.Target]; ok {
+		latency = script[n]
+	}
+	if f.fail[ep.Target] || latency < 0 {
+		return domain.NewFailedProbe(ep, domain.StatusTimeout, errors.New("no answer"))
+	}
+	return domain.NewSuccessfulProbe(ep, latency)
+}
+
+func (f *fakeChecker) CheckWithContext(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	return f.check(ep)
+}
+
+func (f *fakeChecker) CheckPingWithContext(ctx context.Context, ep domain.Endpoint) domain.Probe {
+	return f.check(ep)
+}
+
+func (f *fakeChecker) started() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.calls)
+}
+
+func newTestExecutor(f *fakeChecker, policy domain.ExecutionPolicy, opts ...ExecutorOption) *Executor {
+	return NewExecutor(f, nil, nil, f, policy, opts...)
+}
+
+func tcpEndpoint(t *testing.T, target string) domain.Endpoint {
+	t.Helper()
+	ep, err := domain.NewTCPEndpoint(target, domain.EndpointTypePublic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ep
+}
+
+func icmpEndpoint(t *testing.T, host string) domain.Endpoint {
+	t.Helper()
+	ep, err := domain.NewICMPEndpoint(host, domain.EndpointTypePublic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ep
+}
+
+func targets(probes []domain.Probe) []string {
+	out := make([]string, len(probes))
+	for i, p := range probes {
+		out[i] = p.Endpoint.Target
+	}
+	return out
+}
+
+func TestRunKeepsEndpointOrderWithConcurrency(t *testing.T) {
+	var endpoints []domain.Endpoint
+	var want []string
+	for _, target := range []string{"a:1", "b:1", "c:1", "d:1", "e:1", "f:1", "g:1", "h:1"} {
+		endpoints = append(endpoints, tcpEndpoint(t, target))
+		want = append(want, target)
+	}
+	// the first endpoints finish last
+	f := &fakeChecker{delay: func(target string) time.Duration {
+		return time.Duration('i'-target[0]) * 2 * time.Millisecond
+	}}
+	e := newTestExecutor(f, domain.PolicyExhaustive, WithConcurrency(4))
+
+	for range 5 {
+		result := e.Run(context.Background(), domain.NetTestConfig{DirectEndpoints: endpoints})
+		if got := targets(result.Probes); !slices.Equal(got, want) {
+			t.Fatalf("probe order = %v, want %v", got, want)
+		}
+	}
+	if f.maxInFlight < 2 || f.maxInFlight > 4 {
+		t.Errorf("max checks in flight = %d, want between 2 and 4", f.maxInFlight)
+	}
+}
+
+func TestRunOptimizedChecksICMPFirst(t *testing.T) {
+	endpoints := []domain.Endpoint{
+		tcpEndpoint(t, "a:1"),
+		icmpEndpoint(t, "ping1"),
+		tcpEndpoint(t, "b:1"),
+		icmpEndpoint(t, "ping2"),
+		tcpEndpoint(t, "c:1"),
+	}
+	wantOrder := []string{"ping1", "ping2", "a:1", "b:1", "c:1"}
+
+	t.Run("icmp answers", func(t *testing.T) {
+		f := &fakeChecker{fail: map[string]bool{"ping1": true}}
+		e := newTestExecutor(f, domain.PolicyOptimized, WithConcurrency(3))
+		result := e.Run(context.Background(), domain.NetTestConfig{DirectEndpoints: endpoints})
+
+		if got := targets(result.Probes); !slices.Equal(got, wantOrder) {
+			t.Errorf("probe order = %v, want %v", got, wantOrder)
+		}
+		calls := f.started()
+		if len(calls) != len(endpoints) {
+			t.Fatalf("checks run = %v, want all %d endpoints", calls, len(endpoints))
+		}
+		if !slices.ContainsFunc(calls[:2], func(s string) bool { return s == "ping1" }) ||
+			!slices.ContainsFunc(calls[:2], func(s string) bool { return s == "ping2" }) {
+			t.Errorf("checks started in order %v, want the ICMP endpoints first", calls)
+		}
+		for _, p := range result.Probes {
+			if p.IsSkipped() {
+				t.Errorf("probe %s skipped although an ICMP endpoint answered", p.Endpoint.Target)
+			}
+		}
+	})
+
+	t.Run("icmp silent", func(t *testing.T) {
+		f := &fakeChecker{fail: map[string]bool{"ping1": true, "ping2": true}}
+		e := newTestExecutor(f, domain.PolicyOptimized, WithConcurrency(3))
+		result := e.Run(context.Background(), domain.NetTestConfig{DirectEndpoints: endpoints})
+
+		if got := targets(result.Probes); !slices.Equal(got, wantOrder) {
+			t.Errorf("probe order = %v, want %v", got, wantOrder)
+		}
+		if calls := f.started(); len(calls) != 2 {
+			t.Errorf("checks run = %v, want only the ICMP endpoints", calls)
+		}
+		for _, p := range result.Probes[2:] {
+			if !p.IsSkipped() {
+				t.Errorf("probe %s = %s, want skipped", p.Endpoint.Target, p.Status)
+			}
+		}
+	})
+
+	t.Run("no icmp endpoints", func(t *testing.T) {
+		f := &fakeChecker{}
+		e := newTestExecutor(f, domain.PolicyOptimized)
+		result := e.Run(context.Background(), domain.NetTestConfig{DirectEndpoints: []domain.Endpoint{tcpEndpoint(t, "a:1")}})
+
+		if calls := f.started(); len(calls) != 0 {
+			t.Errorf("checks run = %v, want none without an answering ICMP endpoint", calls)
+		}
+		if len(result.Probes) != 1 || !result.Probes[0].IsSkipped() {
+			t.Errorf("probes = %v, want the tcp endpoint skipped", result.Probes)
+		}
+	})
+}
+
+func TestSampleThresholds(t *testing.T) {
+	tests := []struct {
+		name        string
+		latencies   []float64
+		sampling    domain.Sampling
+		wantStatus  domain.Status
+		wantLatency float64
+		wantError   string
+	}{
+		{
+			name:        "all received",
+			latencies:   []float64{10, 12, 14, 12},
+			sampling:    domain.Sampling{Count: 4, MaxLossPercent: 20},
+			wantStatus:  domain.StatusPass,
+			wantLatency: 12,
+		},
+		{
+			name:       "nothing received",
+			latencies:  []float64{-1, -1, -1},
+			sampling:   domain.Sampling{Count: 3, MaxLossPercent: 20},
+			wantStatus: domain.StatusTimeout,
+			wantError:  "no answer",
+		},
+		{
+			name:        "loss at the limit",
+			latencies:   []float64{10, -1, 10, 10, 10},
+			sampling:    domain.Sampling{Count: 5, MaxLossPercent: 20},
+			wantStatus:  domain.StatusPass,
+			wantLatency: 10,
+		},
+		{
+			name:        "loss above the limit",
+			latencies:   []float64{10, -1, -1, 10, 10},
+			sampling:    domain.Sampling{Count: 5, MaxLossPercent: 20},
+			wantStatus:  domain.StatusWarning,
+			wantLatency: 10,
+			wantError:   "40% loss (2/5 samples), limit 20%",
+		},
+		{
+			name:        "jitter above the limit",
+			latencies:   []float64{10, 30, 10},
+			sampling:    domain.Sampling{Count: 3, MaxLossPercent: 20, MaxJitterMs: 5},
+			wantStatus:  domain.StatusWarning,
+			wantLatency: 50.0 / 3,
+			wantError:   "jitter 20.00 ms, limit 5.00 ms",
+		},
+		{
+			name:        "jitter check disabled",
+			latencies:   []float64{10, 30, 10},
+			sampling:    domain.Sampling{Count: 3, MaxLossPercent: 20},
+			wantStatus:  domain.StatusPass,
+			wantLatency: 50.0 / 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := tcpEndpoint(t, "a:1")
+			ep.Sampling = tt.sampling
+			f := &fakeChecker{script: map[string][]float64{"a:1": tt.latencies}}
+			p := newTestExecutor(f, domain.PolicyExhaustive).checkEndpoint(context.Background(), ep)
+
+			if p.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", p.Status, tt.wantStatus)
+			}
+			if p.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", p.Error, tt.wantError)
+			}
+			if math.Abs(p.LatencyMs-tt.wantLatency) > 1e-9 {
+				t.Errorf("latency = %v, want the average %v", p.LatencyMs, tt.wantLatency)
+			}
+			if p.Stats == nil || p.Stats.Samples != len(tt.latencies) {
+				t.Fatalf("stats = %+v, want %d samples", p.Stats, len(tt.latencies))
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+// DefaultHistorySize is the number of results a History keeps by default.
+const DefaultHistorySize = 120
+
+// History keeps the latest results of periodic runs and counts endpoint
+// uptime over every run it has seen, not only the retained ones.
+type History struct {
+	mu        sync.Mutex
+	size      int
+	results   []domain.ConnectivityResult
+	runs      int
+	connected int
+	uptime    map[string]*domain.EndpointUptime
+	order     []string
+}
+
+func NewHistory(size int) *History {
+	if size < 1 {
+		size = DefaultHistorySize
+	}
+	return &History{size: size, uptime: make(map[string]*domain.EndpointUptime)}
+}
+
+// Add records result and returns the transitions from the previous one.
+// The first result has no transitions.
+func (h *History) Add(result domain.ConnectivityResult) []domain.Transition {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var transitions []domain.Transition
+	if n := len(h.results); n > 0 {
+		transitions = domain.CompareResults(h.results[n-1], result)
+	}
+
+	h.results = append(h.results, result)
+	if len(h.results) > h.size {
+		h.results = h.results[len(h.results)-h.size:]
+	}
+
+	h.runs++
+	if result.IsConnected {
+		h.connected++
+	}
+	for _, p := range result.Probes {
+		key := p.Endpoint.Key()
+		u, ok := h.uptime[key]
+		if !ok {
+			u = &domain.EndpointUptime{Endpoint: p.Endpoint}
+			h.uptime[key] = u
+			h.order = append(h.order, key)
+		}
+		if p.IsSkipped() {
+			continue
+		}
+		u.Runs++
+		if p.IsSuccessful() {
+			u.Passed++
+		}
+	}
+	return transitions
+}
+
+// Results returns the retained results, oldest first.
+func (h *History) Results() []domain.ConnectivityResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]domain.ConnectivityResult(nil), h.results...)
+}
+
+// Latest returns the most recent result, ok is false before the first run.
+func (h *History) Latest() (domain.ConnectivityResult, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.results) == 0 {
+		return domain.ConnectivityResult{}, false
+	}
+	return h.results[len(h.results)-1], true
+}
+
+// Runs returns the number of recorded runs and how many of them were connected.
+func (h *History) Runs() (runs, connected int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.runs, h.connected
+}
+
+// Uptime returns the per endpoint counters in first seen order.
+func (h *History) Uptime() []domain.EndpointUptime {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]domain.EndpointUptime, 0, len(h.order))
+	for _, key := range h.order {
+		out = append(out, *h.uptime[key])
+	}
+	return out
+}
+
+// Watcher reruns the executor on a fixed interval and feeds a History.
+type Watcher struct {
+	executor   *Executor
+	config     domain.NetTestConfig
+	interval   time.Duration
+	runTimeout time.Duration
+	history    *History
+}
+
+// NewWatcher creates a watcher running config every interval. Each run is
+// bounded by runTimeout, 0 leaves it bounded by the parent context only.
+func NewWatcher(executor *Executor, config domain.NetTestConfig, interval, runTimeout time.Duration, history *History) *Watcher {
+	return &Watcher{
+		executor:   executor,
+		config:     config,
+		interval:   interval,
+		runTimeout: runTimeout,
+		history:    history,
+	}
+}
+
+func (w *Watcher) History() *History { return w.history }
+
+// Run executes the first run immediately and then one run per interval until
+// ctx is cancelled. A run that takes longer than the interval delays the next
+// one instead of overlapping it. onRun is called after every completed run;
+// a run interrupted by the cancellation is not recorded.
+func (w *Watcher) Run(ctx context.Context, onRun func(domain.ConnectivityResult, []domain.Transition)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		result, ok := w.runOnce(ctx)
+		if !ok {
+			return
+		}
+		transitions := w.history.Add(result)
+		if onRun != nil {
+			onRun(result, transitions)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) runOnce(ctx context.Context) (domain.ConnectivityResult, bool) {
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if w.runTimeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, w.runTimeout)
+	}
+	defer cancel()
+
+	result := w.executor.Run(runCtx, w.config)
+	return result, ctx.Err() == nil
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func TestHistory(t *testing.T) {
+	a := domain.MustNewTCPEndpoint("a:1", domain.EndpointTypePublic, "")
+	b := domain.MustNewTCPEndpoint("b:1", domain.EndpointTypePublic, "")
+	pass := func(ep domain.Endpoint) domain.Probe { return domain.NewSuccessfulProbe(ep, 1) }
+	fail := func(ep domain.Endpoint) domain.Probe {
+		return domain.NewFailedProbe(ep, domain.StatusTimeout, errors.New("no answer"))
+	}
+	run := func(mode domain.ConnectivityMode, probes ...domain.Probe) domain.ConnectivityResult {
+		return domain.ConnectivityResult{Mode: mode, IsConnected: mode != domain.ModeNone, Probes: probes}
+	}
+
+	h := NewHistory(2)
+	if _, ok := h.Latest(); ok {
+		t.Fatal("Latest before the first run must report no result")
+	}
+
+	steps := []struct {
+		result          domain.ConnectivityResult
+		wantTransitions int
+	}{
+		{run(domain.ModeDirect, pass(a), pass(b)), 0}, // first run, nothing to compare
+		{run(domain.ModeDirect, pass(a), pass(b)), 0}, // unchanged
+		{run(domain.ModeNone, fail(a), pass(b)), 2},   // mode and a: pass -> fail
+		{run(domain.ModeDirect, pass(a), domain.NewSkippedProbe(b, "gated")), 2},
+	}
+	for i, s := range steps {
+		if got := h.Add(s.result); len(got) != s.wantTransitions {
+			t.Errorf("run %d: transitions = %v, want %d", i+1, got, s.wantTransitions)
+		}
+	}
+
+	if n := len(h.Results()); n != 2 {
+		t.Errorf("retained %d results, want the history size 2", n)
+	}
+	if latest, _ := h.Latest(); latest.Mode != domain.ModeDirect || !latest.Probes[1].IsSkipped() {
+		t.Errorf("Latest = %+v, want the last run", latest)
+	}
+	if runs, connected := h.Runs(); runs != 4 || connected != 3 {
+		t.Errorf("Runs = %d, %d connected, want 4, 3 connected", runs, connected)
+	}
+
+	// uptime counts every run, also those no longer retained, and skips
+	// runs in which the endpoint was not probed
+	uptime := h.Uptime()
+	if len(uptime) != 2 || uptime[0].Endpoint.Target != "a:1" || uptime[1].Endpoint.Target != "b:1" {
+		t.Fatalf("uptime = %+v, want a:1 and b:1 in first seen order", uptime)
+	}
+	if u := uptime[0]; u.Runs != 4 || u.Passed != 3 || u.Percent() != 75 {
+		t.Errorf("a:1 uptime = %d/%d (%v%%), want 3/4 (75%%)", u.Passed, u.Runs, u.Percent())
+	}
+	if u := uptime[1]; u.Runs != 3 || u.Passed != 3 || u.Percent() != 100 {
+		t.Errorf("b:1 uptime = %d/%d (%v%%), want 3/3 (100%%)", u.Passed, u.Runs, u.Percent())
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+type DiagnosisSpec struct {
+	DisableBuiltin bool       `json:"disableBuiltin" yaml:"disableBuiltin"`
+	Rules          []RuleSpec `json:"rules"          yaml:"rules"`
+}
+
+type RuleSpec struct {
+	ID          string        `json:"id"          yaml:"id"`
+	Title       string        `json:"title"       yaml:"title"`
+	Severity    string        `json:"severity"    yaml:"severity"`
+	Remediation string        `json:"remediation" yaml:"remediation"`
+	When        []MatcherSpec `json:"when"        yaml:"when"`
+	Unless      []MatcherSpec `json:"unless"      yaml:"unless"`
+}
+
+type MatcherSpec struct {
+	Kind          string   `json:"kind"          yaml:"kind"`
+	Group         string   `json:"group"         yaml:"group"`
+	Target        string   `json:"target"        yaml:"target"`
+	Status        string   `json:"status"        yaml:"status"`
+	ErrorContains []string `json:"errorContains" yaml:"errorContains"`
+}
+
+// diagnosisRules returns nil when the config does not touch diagnosis,
+// so the built-in rules apply.
+func diagnosisRules(spec *DiagnosisSpec) ([]domain.DiagnosisRule, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	custom := make([]domain.DiagnosisRule, 0, len(spec.Rules))
+	for _, rs := range spec.Rules {
+		if rs.ID == "" {
+			return nil, domain.ErrInvalidConfig("diagnosis rule without id")
+		}
+		if seen[rs.ID] {
+			return nil, domain.ErrInvalidConfig(fmt.Sprintf("duplicate diagnosis rule %q", rs.ID))
+		}
+		seen[rs.ID] = true
+
+		rule, err := rs.toDomain()
+		if err != nil {
+			return nil, fmt.Errorf("diagnosis rule %q: %w", rs.ID, err)
+		}
+		custom = append(custom, rule)
+	}
+
+	if spec.DisableBuiltin {
+		return custom, nil
+	}
+	return domain.MergeDiagnosisRules(domain.DefaultDiagnosisRules(), custom), nil
+}
+
+func (rs RuleSpec) toDomain() (domain.DiagnosisRule, error) {
+	sev := domain.SeverityWarning
+	if rs.Severity != "" {
+		var ok bool
+		if sev, ok = domain.ParseSeverity(rs.Severity); !ok {
+			return domain.DiagnosisRule{}, domain.ErrInvalidConfig(fmt.Sprintf("unknown severity %q", rs.Severity))
+		}
+	}
+	if len(rs.When) == 0 {
+		return domain.DiagnosisRule{}, domain.ErrInvalidConfig("rule needs at least one 'when' matcher")
+	}
+
+	rule := domain.DiagnosisRule{
+		ID:          rs.ID,
+		Title:       rs.Title,
+		Severity:    sev,
+		Remediation: rs.Remediation,
+	}
+	if rule.Title == "" {
+		rule.Title = rs.ID
+	}
+	for _, ms := range rs.When {
+		m, err := ms.toDomain()
+		if err != nil {
+			return domain.DiagnosisRule{}, err
+		}
+		rule.When = append(rule.When, m)
+	}
+	for _, ms := range rs.Unless {
+		m, err := ms.toDomain()
+		if err != nil {
+			return domain.DiagnosisRule{}, err
+		}
+		rule.Unless = append(rule.Unless, m)
+	}
+	return rule, nil
+}
+
+func (ms MatcherSpec) toDomain() (domain.ProbeMatcher, error) {
+	switch ms.Kind {
+	case "", "icmp", "tcp", "udp", "http", "dns", "tls":
+	default:
+		return domain.ProbeMatcher{}, domain.ErrInvalidConfig(fmt.Sprintf("unknown kind %q", ms.Kind))
+	}
+	if ms.Group != "" {
+		if _, ok := domain.ParseProbeGroup(ms.Group); !ok {
+			return domain.ProbeMatcher{}, domain.ErrInvalidConfig(fmt.Sprintf("unknown group %q", ms.Group))
+		}
+	}
+	switch ms.Status {
+	case "", "pass", "fail":
+	default:
+		if _, ok := domain.ParseStatusKey(ms.Status); !ok {
+			return domain.ProbeMatcher{}, domain.ErrInvalidConfig(fmt.Sprintf("unknown status %q", ms.Status))
+		}
+	}
+	return domain.ProbeMatcher{
+		Kind:          ms.Kind,
+		Group:         ms.Group,
+		Target:        ms.Target,
+		Status:        ms.Status,
+		ErrorContains: ms.ErrorContains,
+	}, nil
+}
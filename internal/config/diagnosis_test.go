@@ -0,0 +1,66 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func TestDiagnosisRulesRejectsInvalidSpecs(t *testing.T) {
+	when := []MatcherSpec{{Kind: "tcp", Status: "fail"}}
+	tests := []struct {
+		name    string
+		rules   []RuleSpec
+		wantErr string
+	}{
+		{"unknown kind", []RuleSpec{{ID: "r", When: []MatcherSpec{{Kind: "smtp"}}}}, `unknown kind "smtp"`},
+		{"unknown kind in unless", []RuleSpec{{ID: "r", When: when, Unless: []MatcherSpec{{Kind: "ftp"}}}}, `unknown kind "ftp"`},
+		{"unknown group", []RuleSpec{{ID: "r", When: []MatcherSpec{{Group: "lan"}}}}, `unknown group "lan"`},
+		{"unknown status", []RuleSpec{{ID: "r", When: []MatcherSpec{{Status: "down"}}}}, `unknown status "down"`},
+		{"unknown severity", []RuleSpec{{ID: "r", Severity: "fatal", When: when}}, `unknown severity "fatal"`},
+		{"no when matcher", []RuleSpec{{ID: "r"}}, "at least one 'when' matcher"},
+		{"missing id", []RuleSpec{{When: when}}, "without id"},
+		{"duplicate id", []RuleSpec{{ID: "r", When: when}, {ID: "r", When: when}}, `duplicate diagnosis rule "r"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := diagnosisRules(&DiagnosisSpec{Rules: tt.rules})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiagnosisRules(t *testing.T) {
+	custom := RuleSpec{
+		ID:   "udp-blocked",
+		When: []MatcherSpec{{Kind: "udp", Group: "vpn", Status: "timeout", ErrorContains: []string{"no response"}}},
+	}
+
+	rules, err := diagnosisRules(nil)
+	if err != nil || rules != nil {
+		t.Errorf("diagnosisRules(nil) = %v, %v, want nil so the built-in rules apply", rules, err)
+	}
+
+	rules, err = diagnosisRules(&DiagnosisSpec{Rules: []RuleSpec{custom}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(domain.DefaultDiagnosisRules()) + 1; len(rules) != n {
+		t.Errorf("got %d rules, want the %d built-in rules and the custom one", len(rules), n-1)
+	}
+	last := rules[len(rules)-1]
+	if last.Title != "udp-blocked" || last.Severity != domain.SeverityWarning {
+		t.Errorf("custom rule = %+v, want the id as title and warning severity", last)
+	}
+
+	rules, err = diagnosisRules(&DiagnosisSpec{DisableBuiltin: true, Rules: []RuleSpec{custom}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].ID != "udp-blocked" {
+		t.Errorf("disableBuiltin kept %d rules, want only the custom one", len(rules))
+	}
+}
@@ -1,12 +1,20 @@
 package config
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/azargarov/rsvpck/internal/domain"
 	"gopkg.in/yaml.v3"
@@ -18,6 +26,32 @@ type FileSpec struct {
 	VPNEndpoints    []EndpointSpec `json:"vpnEndpoints"    yaml:"vpnEndpoints"`
 	DirectEndpoints []EndpointSpec `json:"directEndpoints" yaml:"directEndpoints"`
 	ProxyEndpoints  []EndpointSpec `json:"proxyEndpoints"  yaml:"proxyEndpoints"`
+	Concurrency     int            `json:"concurrency"     yaml:"concurrency"`
+	Policy          string         `json:"policy"          yaml:"policy"`
+	Diagnosis       *DiagnosisSpec `json:"diagnosis"       yaml:"diagnosis"`
+	ICMPMode        string         `json:"icmpMode"        yaml:"icmpMode"`
+	Sampling        SamplingSpec   `json:"sampling"        yaml:"sampling"`
+	Retry           RetrySpec      `json:"retry"           yaml:"retry"`
+
+	// Timeouts per endpoint kind (icmp, tcp, udp, dns, http, tls) and "default"
+	// for every kind not listed. Endpoints may set their own timeout.
+	Timeouts map[string]Duration `json:"timeouts" yaml:"timeouts"`
+}
+
+// RetrySpec is the global retry default, an endpoint retry block overrides
+// the fields it sets.
+type RetrySpec struct {
+	MaxAttempts int       `json:"maxAttempts" yaml:"maxAttempts"`
+	Backoff     *Duration `json:"backoff"     yaml:"backoff"`
+	MaxBackoff  *Duration `json:"maxBackoff"  yaml:"maxBackoff"`
+}
+
+// SamplingSpec is the global sampling default, endpoints may override each field.
+type SamplingSpec struct {
+	Count          int      `json:"count"          yaml:"count"`
+	Interval       Duration `json:"interval"       yaml:"interval"`
+	MaxLossPercent *float64 `json:"maxLossPercent" yaml:"maxLossPercent"`
+	MaxJitterMs    *float64 `json:"maxJitterMs"    yaml:"maxJitterMs"`
 }
 
 type EndpointSpec struct {
@@ -26,6 +60,43 @@ type EndpointSpec struct {
 	Kind     string `json:"kind"     yaml:"kind"`     
 	Note     string `json:"note"     yaml:"note"`     
 	UseProxy bool   `json:"useProxy" yaml:"useProxy"` 
+
+	// tls only. Proxies default to vpnIPs when omitted, an explicit empty
+	// list disables the fallback.
+	SNI      string   `json:"sni"      yaml:"sni"`
+	Proxies  []string `json:"proxies"  yaml:"proxies"`
+
+	// tls assertions. minVersion is "1.0" to "1.3"; the expect patterns are
+	// regular expressions on the leaf subject and issuer; pins are base64
+	// SHA-256 public key hashes, optionally prefixed with "sha256/".
+	MinVersion        string   `json:"minVersion"        yaml:"minVersion"`
+	ExpectSubject     string   `json:"expectSubject"     yaml:"expectSubject"`
+	ExpectIssuer      string   `json:"expectIssuer"      yaml:"expectIssuer"`
+	ExpiryWarningDays int      `json:"expiryWarningDays" yaml:"expiryWarningDays"`
+	Pins              []string `json:"pins"              yaml:"pins"`
+
+	// tls interception detection: the chain is fetched directly and via every
+	// proxy, and each leaf must be issued by one of expectedIssuers (regular
+	// expressions) and, when given, have one of leafFingerprints (hex SHA-256
+	// of the certificate, colons allowed).
+	ExpectedIssuers  []string `json:"expectedIssuers"  yaml:"expectedIssuers"`
+	LeafFingerprints []string `json:"leafFingerprints" yaml:"leafFingerprints"`
+
+	// udp only. The datagram to send, hex or base64 encoded (at most one,
+	// none sends an empty datagram), and a regular expression the response
+	// must match. Without expect no response is required.
+	PayloadHex    string `json:"payloadHex"    yaml:"payloadHex"`
+	PayloadBase64 string `json:"payloadBase64" yaml:"payloadBase64"`
+	Expect        string `json:"expect"        yaml:"expect"`
+
+	// Sampling overrides, zero values inherit the global sampling settings.
+	Count          int       `json:"count"          yaml:"count"`
+	Interval       *Duration `json:"interval"       yaml:"interval"`
+	MaxLossPercent *float64  `json:"maxLossPercent" yaml:"maxLossPercent"`
+	MaxJitterMs    *float64  `json:"maxJitterMs"    yaml:"maxJitterMs"`
+
+	Retry   *RetrySpec `json:"retry"   yaml:"retry"`
+	Timeout *Duration  `json:"timeout" yaml:"timeout"`
 }
 
 func LoadFromFile(path string) (domain.NetTestConfig, error) {
@@ -39,6 +110,11 @@ func LoadFromFile(path string) (domain.NetTestConfig, error) {
 	return parseConfigBytes(raw, filepath.Ext(path))
 }
 
+// Parse reads a YAML or JSON config from memory, e.g. one posted to the API.
+func Parse(b []byte) (domain.NetTestConfig, error) {
+	return parseConfigBytes(b, ".yaml")
+}
+
 func parseConfigBytes(b []byte, ext string) (domain.NetTestConfig, error) {
 	ext = strings.ToLower(ext)
 	var spec FileSpec
@@ -68,26 +144,78 @@ func parseConfigBytes(b []byte, ext string) (domain.NetTestConfig, error) {
 }
 
 func specToDomain(spec FileSpec) (domain.NetTestConfig, error) {
-	toEndpoint := func(s EndpointSpec) (domain.Endpoint, error) {
+	if err := spec.Sampling.validate(); err != nil {
+		return domain.NetTestConfig{}, err
+	}
+	if err := spec.Retry.validate(); err != nil {
+		return domain.NetTestConfig{}, err
+	}
+	if err := validateTimeouts(spec.Timeouts); err != nil {
+		return domain.NetTestConfig{}, err
+	}
+
+	toKindEndpoint := func(s EndpointSpec) (domain.Endpoint, error) {
 		etype := domain.EndpointTypePublic
 		if s.Type == "vpn" {
 			etype = domain.EndpointTypeVPN
 		}
 
+		if field := s.tlsAssertionField(); field != "" && s.Kind != "tls" {
+			return domain.Endpoint{}, fmt.Errorf("%s only applies to tls endpoints", field)
+		}
+
+		// configs may come from the user, invalid targets are errors, not panics
 		switch s.Kind {
 		case "icmp":
-			return domain.MustNewICMPEndpoint(s.Target, etype, s.Note), nil
+			return domain.NewICMPEndpoint(s.Target, etype, s.Note)
 		case "dns":
-			return domain.MustNewDNSEndpoint(s.Target, etype, s.Note), nil
+			return domain.NewDNSEndpoint(s.Target, etype, s.Note)
 		case "tcp":
-			return domain.MustNewTCPEndpoint(s.Target, etype, s.Note), nil
+			return domain.NewTCPEndpoint(s.Target, etype, s.Note)
 		case "http":
-			return domain.MustNewHTTPEndpoint(s.Target, etype, s.UseProxy, spec.ProxyURL, s.Note), nil
+			ep, err := domain.NewHTTPEndpoint(s.Target, etype, s.Note)
+			if err == nil && s.UseProxy {
+				ep.SetProxy(spec.ProxyURL)
+			}
+			return ep, err
+		case "tls":
+			proxies := s.Proxies
+			if proxies == nil {
+				proxies = spec.VPNIPs
+			}
+			ep, err := domain.NewTLSEndpoint(s.Target, etype, s.SNI, proxies, s.Note)
+			if err != nil {
+				return ep, err
+			}
+			return ep, s.applyTLSAssertions(&ep.TLS)
+		case "udp":
+			payload, err := s.udpPayload()
+			if err != nil {
+				return domain.Endpoint{}, err
+			}
+			return domain.NewUDPEndpoint(s.Target, etype, payload, s.Expect, s.Note)
 		default:
 			return domain.Endpoint{}, fmt.Errorf("unknown endpoint kind: %s", s.Kind)
 		}
 	}
 
+	toEndpoint := func(s EndpointSpec) (domain.Endpoint, error) {
+		ep, err := toKindEndpoint(s)
+		if err != nil {
+			return domain.Endpoint{}, err
+		}
+		if ep.Sampling, err = resolveSampling(spec.Sampling, s); err != nil {
+			return domain.Endpoint{}, err
+		}
+		if ep.Retry, err = resolveRetry(spec.Retry, s.Retry); err != nil {
+			return domain.Endpoint{}, err
+		}
+		if ep.Timeout, err = resolveTimeout(spec.Timeouts, s); err != nil {
+			return domain.Endpoint{}, err
+		}
+		return ep, nil
+	}
+
 	var vpn, direct, proxy []domain.Endpoint
 	var err error
 
@@ -119,6 +247,236 @@ func specToDomain(spec FileSpec) (domain.NetTestConfig, error) {
 	if err != nil {
 		return domain.NetTestConfig{}, err
 	}
+	if spec.Concurrency < 0 {
+		return domain.NetTestConfig{}, domain.ErrInvalidConfig("concurrency must not be negative")
+	}
+
+	cfg, err := domain.NewNetTestConfig(vpn, direct, proxy, spec.ProxyURL, spec.VPNIPs)
+	if err != nil {
+		return domain.NetTestConfig{}, err
+	}
+	cfg.Concurrency = spec.Concurrency
+	if spec.Policy != "" {
+		policy, ok := domain.ParseExecutionPolicy(spec.Policy)
+		if !ok {
+			return domain.NetTestConfig{}, domain.ErrInvalidConfig(fmt.Sprintf("unknown policy %q", spec.Policy))
+		}
+		cfg.Policy = &policy
+	}
+	if cfg.DiagnosisRules, err = diagnosisRules(spec.Diagnosis); err != nil {
+		return domain.NetTestConfig{}, err
+	}
+	switch spec.ICMPMode {
+	case "", "auto", "native", "exec":
+		cfg.ICMPMode = spec.ICMPMode
+	default:
+		return domain.NetTestConfig{}, domain.ErrInvalidConfig(fmt.Sprintf("unknown icmpMode %q", spec.ICMPMode))
+	}
+	return cfg, nil
+}
+
+func (s SamplingSpec) validate() error {
+	switch {
+	case s.Count < 0:
+		return domain.ErrInvalidConfig("sampling count must not be negative")
+	case s.MaxLossPercent != nil && (*s.MaxLossPercent < 0 || *s.MaxLossPercent > 100):
+		return domain.ErrInvalidConfig("maxLossPercent must be between 0 and 100")
+	case s.MaxJitterMs != nil && *s.MaxJitterMs < 0:
+		return domain.ErrInvalidConfig("maxJitterMs must not be negative")
+	}
+	return nil
+}
+
+// resolveSampling applies endpoint overrides on top of the global sampling spec.
+func resolveSampling(global SamplingSpec, ep EndpointSpec) (domain.Sampling, error) {
+	merged := global
+	if ep.Count != 0 {
+		merged.Count = ep.Count
+	}
+	if ep.Interval != nil {
+		merged.Interval = *ep.Interval
+	}
+	if ep.MaxLossPercent != nil {
+		merged.MaxLossPercent = ep.MaxLossPercent
+	}
+	if ep.MaxJitterMs != nil {
+		merged.MaxJitterMs = ep.MaxJitterMs
+	}
+	if err := merged.validate(); err != nil {
+		return domain.Sampling{}, err
+	}
 
-	return domain.NewNetTestConfig(vpn, direct, proxy, spec.ProxyURL, spec.VPNIPs)
+	out := domain.Sampling{Count: merged.Count, Interval: merged.Interval.Std(), MaxLossPercent: domain.DefaultMaxLossPercent}
+	if merged.MaxLossPercent != nil {
+		out.MaxLossPercent = *merged.MaxLossPercent
+	}
+	if merged.MaxJitterMs != nil {
+		out.MaxJitterMs = *merged.MaxJitterMs
+	}
+	return out, nil
+}
+
+func (r RetrySpec) validate() error {
+	if r.MaxAttempts < 0 {
+		return domain.ErrInvalidConfig("retry maxAttempts must not be negative")
+	}
+	return nil
+}
+
+// resolveRetry applies an endpoint retry block on top of the global one.
+func resolveRetry(global RetrySpec, ep *RetrySpec) (domain.RetryPolicy, error) {
+	merged := global
+	if ep != nil {
+		if ep.MaxAttempts != 0 {
+			merged.MaxAttempts = ep.MaxAttempts
+		}
+		if ep.Backoff != nil {
+			merged.Backoff = ep.Backoff
+		}
+		if ep.MaxBackoff != nil {
+			merged.MaxBackoff = ep.MaxBackoff
+		}
+	}
+	if err := merged.validate(); err != nil {
+		return domain.RetryPolicy{}, err
+	}
+
+	out := domain.RetryPolicy{MaxAttempts: merged.MaxAttempts}
+	if merged.Backoff != nil {
+		out.Backoff = merged.Backoff.Std()
+	}
+	if merged.MaxBackoff != nil {
+		out.MaxBackoff = merged.MaxBackoff.Std()
+	}
+	return out, nil
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// applyTLSAssertions validates the tls assertions of s and stores them in opts.
+func (s EndpointSpec) applyTLSAssertions(opts *domain.TLSOptions) error {
+	if s.MinVersion != "" {
+		v, ok := tlsVersions[s.MinVersion]
+		if !ok {
+			return fmt.Errorf("minVersion: unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", s.MinVersion)
+		}
+		opts.MinVersion = v
+	}
+	var err error
+	if s.ExpectSubject != "" {
+		if opts.ExpectSubject, err = regexp.Compile(s.ExpectSubject); err != nil {
+			return fmt.Errorf("expectSubject: %w", err)
+		}
+	}
+	if s.ExpectIssuer != "" {
+		if opts.ExpectIssuer, err = regexp.Compile(s.ExpectIssuer); err != nil {
+			return fmt.Errorf("expectIssuer: %w", err)
+		}
+	}
+	if s.ExpiryWarningDays < 0 {
+		return errors.New("expiryWarningDays must not be negative")
+	}
+	opts.ExpiryWarning = time.Duration(s.ExpiryWarningDays) * 24 * time.Hour
+	for _, pin := range s.Pins {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+		if err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("pins: %q is not a base64 SHA-256 hash", pin)
+		}
+		opts.Pins = append(opts.Pins, base64.StdEncoding.EncodeToString(b))
+	}
+	for _, expr := range s.ExpectedIssuers {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("expectedIssuers: %w", err)
+		}
+		opts.ExpectedIssuers = append(opts.ExpectedIssuers, re)
+	}
+	for _, fp := range s.LeafFingerprints {
+		b, err := hex.DecodeString(strings.ReplaceAll(fp, ":", ""))
+		if err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("leafFingerprints: %q is not a hex SHA-256 hash", fp)
+		}
+		opts.LeafFingerprints = append(opts.LeafFingerprints, hex.EncodeToString(b))
+	}
+	return nil
+}
+
+// tlsAssertionField names the first tls assertion set on s, "" when none is.
+func (s EndpointSpec) tlsAssertionField() string {
+	switch {
+	case s.MinVersion != "":
+		return "minVersion"
+	case s.ExpectSubject != "":
+		return "expectSubject"
+	case s.ExpectIssuer != "":
+		return "expectIssuer"
+	case s.ExpiryWarningDays != 0:
+		return "expiryWarningDays"
+	case len(s.Pins) > 0:
+		return "pins"
+	case len(s.ExpectedIssuers) > 0:
+		return "expectedIssuers"
+	case len(s.LeafFingerprints) > 0:
+		return "leafFingerprints"
+	}
+	return ""
+}
+
+// udpPayload decodes the payload of a udp endpoint.
+func (s EndpointSpec) udpPayload() ([]byte, error) {
+	switch {
+	case s.PayloadHex != "" && s.PayloadBase64 != "":
+		return nil, errors.New("set either payloadHex or payloadBase64, not both")
+	case s.PayloadHex != "":
+		b, err := hex.DecodeString(strings.ReplaceAll(s.PayloadHex, " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("payloadHex: %w", err)
+		}
+		return b, nil
+	case s.PayloadBase64 != "":
+		b, err := base64.StdEncoding.DecodeString(s.PayloadBase64)
+		if err != nil {
+			return nil, fmt.Errorf("payloadBase64: %w", err)
+		}
+		return b, nil
+	}
+	return nil, nil
+}
+
+const defaultTimeoutKey = "default"
+
+var timeoutKinds = []string{"icmp", "tcp", "udp", "dns", "http", "tls"}
+
+func validateTimeouts(timeouts map[string]Duration) error {
+	for _, kind := range slices.Sorted(maps.Keys(timeouts)) {
+		d := timeouts[kind]
+		if kind != defaultTimeoutKey && !slices.Contains(timeoutKinds, kind) {
+			return fmt.Errorf("timeouts: unknown kind %q, expected one of %s or %s",
+				kind, strings.Join(timeoutKinds, ", "), defaultTimeoutKey)
+		}
+		if d == 0 {
+			return fmt.Errorf("timeouts: %s must be greater than 0", kind)
+		}
+	}
+	return nil
+}
+
+// resolveTimeout picks the endpoint timeout, then the kind timeout, then the
+// default entry. 0 leaves the domain default in place.
+func resolveTimeout(timeouts map[string]Duration, ep EndpointSpec) (time.Duration, error) {
+	if ep.Timeout != nil {
+		if *ep.Timeout == 0 {
+			return 0, fmt.Errorf("timeout must be greater than 0")
+		}
+		return ep.Timeout.Std(), nil
+	}
+	if d, ok := timeouts[ep.Kind]; ok {
+		return d.Std(), nil
+	}
+	return timeouts[defaultTimeoutKey].Std(), nil
 }
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestResolveSampling(t *testing.T) {
+	tests := []struct {
+		name    string
+		global  SamplingSpec
+		ep      EndpointSpec
+		want    domain.Sampling
+		wantErr bool
+	}{
+		{
+			name: "no sampling block",
+			want: domain.Sampling{MaxLossPercent: domain.DefaultMaxLossPercent},
+		},
+		{
+			name:   "unset loss limit defaults to 20",
+			global: SamplingSpec{Count: 5, Interval: Duration(100 * time.Millisecond)},
+			want:   domain.Sampling{Count: 5, Interval: 100 * time.Millisecond, MaxLossPercent: 20},
+		},
+		{
+			name:   "explicit zero loss limit",
+			global: SamplingSpec{Count: 5, MaxLossPercent: ptr(0.0)},
+			want:   domain.Sampling{Count: 5},
+		},
+		{
+			name:   "endpoint overrides",
+			global: SamplingSpec{Count: 3, MaxLossPercent: ptr(10.0), MaxJitterMs: ptr(5.0)},
+			ep:     EndpointSpec{Count: 10, Interval: ptr(Duration(time.Second)), MaxLossPercent: ptr(50.0)},
+			want:   domain.Sampling{Count: 10, Interval: time.Second, MaxLossPercent: 50, MaxJitterMs: 5},
+		},
+		{
+			name:    "loss limit above 100",
+			ep:      EndpointSpec{MaxLossPercent: ptr(101.0)},
+			wantErr: true,
+		},
+		{
+			name:    "negative jitter limit",
+			global:  SamplingSpec{MaxJitterMs: ptr(-1.0)},
+			wantErr: true,
+		},
+		{
+			name:    "negative count",
+			ep:      EndpointSpec{Count: -2},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSampling(tt.global, tt.ep)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("sampling = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTimeout(t *testing.T) {
+	timeouts := map[string]Duration{
+		"tcp":             Duration(2 * time.Second),
+		defaultTimeoutKey: Duration(5 * time.Second),
+	}
+	tests := []struct {
+		name     string
+		timeouts map[string]Duration
+		ep       EndpointSpec
+		want     time.Duration
+		wantErr  bool
+	}{
+		{name: "endpoint timeout", timeouts: timeouts, ep: EndpointSpec{Kind: "tcp", Timeout: ptr(Duration(time.Second))}, want: time.Second},
+		{name: "kind timeout", timeouts: timeouts, ep: EndpointSpec{Kind: "tcp"}, want: 2 * time.Second},
+		{name: "default entry", timeouts: timeouts, ep: EndpointSpec{Kind: "dns"}, want: 5 * time.Second},
+		{name: "no timeouts", ep: EndpointSpec{Kind: "dns"}},
+		{name: "zero endpoint timeout", timeouts: timeouts, ep: EndpointSpec{Kind: "tcp", Timeout: ptr(Duration(0))}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTimeout(tt.timeouts, tt.ep)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("timeout = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsZeroEndpointTimeout(t *testing.T) {
+	_, err := parseConfigBytes([]byte("directEndpoints:\n  - {kind: tcp, type: public, target: \"example.com:443\", timeout: 0s}\n"), ".yaml")
+	if err == nil || !strings.Contains(err.Error(), "timeout must be greater than 0") {
+		t.Errorf("err = %v, want a timeout config error", err)
+	}
+}
+
+func TestApplyTLSAssertions(t *testing.T) {
+	pin := "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=" // sha256 of nothing
+	tests := []struct {
+		name    string
+		spec    EndpointSpec
+		wantErr string
+		check   func(t *testing.T, opts domain.TLSOptions)
+	}{
+		{
+			name: "no assertions",
+			check: func(t *testing.T, opts domain.TLSOptions) {
+				if opts.MinVersion != 0 || opts.Pins != nil || opts.ExpiryThreshold() != domain.DefaultExpiryWarning {
+					t.Errorf("opts = %+v, want the defaults", opts)
+				}
+			},
+		},
+		{
+			name: "min version",
+			spec: EndpointSpec{MinVersion: "1.2"},
+			check: func(t *testing.T, opts domain.TLSOptions) {
+				if opts.MinVersion != tls.VersionTLS12 {
+					t.Errorf("MinVersion = %x, want TLS 1.2", opts.MinVersion)
+				}
+			},
+		},
+		{name: "unknown version", spec: EndpointSpec{MinVersion: "1.4"}, wantErr: `unknown TLS version "1.4"`},
+		{name: "version with prefix", spec: EndpointSpec{MinVersion: "TLS1.2"}, wantErr: "unknown TLS version"},
+		{
+			name: "pin with and without prefix",
+			spec: EndpointSpec{Pins: []string{pin, "sha256/" + pin}},
+			check: func(t *testing.T, opts domain.TLSOptions) {
+				if len(opts.Pins) != 2 || opts.Pins[0] != pin || opts.Pins[1] != pin {
+					t.Errorf("Pins = %v, want both normalized to %s", opts.Pins, pin)
+				}
+			},
+		},
+		{name: "short pin", spec: EndpointSpec{Pins: []string{"sha256/AAAA"}}, wantErr: "is not a base64 SHA-256 hash"},
+		{name: "pin not base64", spec: EndpointSpec{Pins: []string{"not base64!"}}, wantErr: "is not a base64 SHA-256 hash"},
+		{name: "pin with other prefix", spec: EndpointSpec{Pins: []string{"sha1/" + pin}}, wantErr: "is not a base64 SHA-256 hash"},
+		{name: "negative days", spec: EndpointSpec{ExpiryWarningDays: -1}, wantErr: "expiryWarningDays must not be negative"},
+		{
+			name: "expiry days",
+			spec: EndpointSpec{ExpiryWarningDays: 30},
+			check: func(t *testing.T, opts domain.TLSOptions) {
+				if opts.ExpiryThreshold() != 30*24*time.Hour {
+					t.Errorf("ExpiryThreshold = %s, want 30 days", opts.ExpiryThreshold())
+				}
+			},
+		},
+		{
+			name: "subject and issuer patterns",
+			spec: EndpointSpec{ExpectSubject: `CN=.*\.example\.com`, ExpectIssuer: "DigiCert"},
+			check: func(t *testing.T, opts domain.TLSOptions) {
+				if !opts.ExpectSubject.MatchString("CN=api.example.com") || !opts.ExpectIssuer.MatchString("CN=DigiCert TLS RSA") {
+					t.Errorf("patterns %q, %q do not match", opts.ExpectSubject, opts.ExpectIssuer)
+				}
+			},
+		},
+		{name: "invalid subject pattern", spec: EndpointSpec{ExpectSubject: "CN=("}, wantErr: "expectSubject"},
+		{name: "invalid issuer pattern", spec: EndpointSpec{ExpectIssuer: "["}, wantErr: "expectIssuer"},
+		{
+			name: "fingerprint with colons",
+			spec: EndpointSpec{LeafFingerprints: []string{strings.Repeat("AB:", 31) + "AB"}},
+			check: func(t *testing.T, opts domain.TLSOptions) {
+				if want := strings.Repeat("ab", 32); len(opts.LeafFingerprints) != 1 || opts.LeafFingerprints[0] != want {
+					t.Errorf("LeafFingerprints = %v, want [%s]", opts.LeafFingerprints, want)
+				}
+			},
+		},
+		{name: "short fingerprint", spec: EndpointSpec{LeafFingerprints: []string{"abcd"}}, wantErr: "is not a hex SHA-256 hash"},
+		{name: "invalid expected issuer", spec: EndpointSpec{ExpectedIssuers: []string{"("}}, wantErr: "expectedIssuers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts domain.TLSOptions
+			err := tt.spec.applyTLSAssertions(&opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, opts)
+		})
+	}
+}
+
+func TestTLSAssertionsOnOtherKinds(t *testing.T) {
+	for _, field := range []string{
+		`minVersion: "1.2"`,
+		`expectSubject: "CN=x"`,
+		`expectIssuer: "DigiCert"`,
+		`expiryWarningDays: 30`,
+		`pins: ["47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="]`,
+		`expectedIssuers: ["DigiCert"]`,
+		`leafFingerprints: ["` + strings.Repeat("ab", 32) + `"]`,
+	} {
+		name := field[:strings.Index(field, ":")]
+		for _, kind := range []string{"tcp", "http", "udp"} {
+			target := "example.com:443"
+			if kind == "http" {
+				target = "https://example.com"
+			}
+			src := "directEndpoints:\n  - {kind: " + kind + ", type: public, target: \"" + target + "\", " + field + "}\n"
+			_, err := parseConfigBytes([]byte(src), ".yaml")
+			if want := name + " only applies to tls endpoints"; err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%s on %s: err = %v, want it to contain %q", name, kind, err, want)
+			}
+		}
+
+		src := "directEndpoints:\n  - {kind: tls, type: public, target: \"example.com:443\", " + field + "}\n"
+		if _, err := parseConfigBytes([]byte(src), ".yaml"); err != nil {
+			t.Errorf("%s on tls: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/azargarov/rsvpck/internal/domain"
+)
+
+const EnvConfigPath = "RSVPCK_CONFIG"
+
+// configFileNames are tried in every search directory, in this order.
+var configFileNames = []string{"rsvpck.yaml", "rsvpck.yml", "rsvpck.json"}
+
+type SourceKind int
+
+const (
+	SourceEmbedded SourceKind = iota
+	SourceFlag
+	SourceEnv
+	SourceSearch
+)
+
+// Source describes where the active configuration was loaded from.
+type Source struct {
+	Kind SourceKind
+	Path string
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SourceFlag:
+		return s.Path + " (-config)"
+	case SourceEnv:
+		return s.Path + " ($" + EnvConfigPath + ")"
+	case SourceSearch:
+		return s.Path
+	default:
+		return "embedded defaults"
+	}
+}
+
+// Resolve loads the configuration using the following precedence:
+//  1. explicit path (the -config flag)
+//  2. $RSVPCK_CONFIG
+//  3. first config file found in SearchDirs()
+//  4. embedded defaults
+//
+// An explicitly requested file that cannot be read is an error, it never
+// falls back to the embedded defaults.
+func Resolve(explicit string) (domain.NetTestConfig, Source, error) {
+	if explicit != "" {
+		src := Source{Kind: SourceFlag, Path: explicit}
+		cfg, err := LoadFromFile(explicit)
+		if err != nil {
+			return domain.NetTestConfig{}, src, fmt.Errorf("read %s: %w", explicit, err)
+		}
+		return cfg, src, nil
+	}
+
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		src := Source{Kind: SourceEnv, Path: path}
+		cfg, err := LoadFromFile(path)
+		if err != nil {
+			return domain.NetTestConfig{}, src, fmt.Errorf("read %s: %w", path, err)
+		}
+		return cfg, src, nil
+	}
+
+	for _, dir := range SearchDirs() {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return domain.NetTestConfig{}, Source{Kind: SourceSearch, Path: path}, err
+			}
+			src := Source{Kind: SourceSearch, Path: path}
+			cfg, err := LoadFromFile(path)
+			if err != nil {
+				return domain.NetTestConfig{}, src, fmt.Errorf("read %s: %w", path, err)
+			}
+			return cfg, src, nil
+		}
+	}
+
+	cfg, err := LoadEmbedded()
+	return cfg, Source{Kind: SourceEmbedded}, err
+}
+
+// SearchDirs returns the directories scanned for a config file:
+// the working directory, $XDG_CONFIG_HOME/rsvpck (~/.config/rsvpck when unset)
+// and /etc/rsvpck.
+func SearchDirs() []string {
+	var dirs []string
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			xdg = filepath.Join(home, ".config")
+		}
+	}
+	if xdg != "" {
+		dirs = append(dirs, filepath.Join(xdg, "rsvpck"))
+	}
+
+	dirs = append(dirs, "/etc/rsvpck")
+	return dirs
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rsvpck.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// An operator supplied file with a bad target must be a config error, not a
+// panic of the process.
+func TestResolveInvalidTargetIsError(t *testing.T) {
+	tests := []struct {
+		kind, target string
+	}{
+		{"icmp", ""},
+		{"icmp", "host:80"},
+		{"dns", "https://example.com"},
+		{"tcp", "example.com"},
+		{"http", "example.com"},
+		{"tls", "example.com"},
+		{"udp", "example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.target, func(t *testing.T) {
+			path := writeConfig(t, "directEndpoints:\n  - kind: "+tt.kind+"\n    type: public\n    target: \""+tt.target+"\"\n")
+			_, src, err := Resolve(path)
+			if err == nil {
+				t.Fatalf("Resolve(%s) = nil error, want an invalid target error", path)
+			}
+			if src.Kind != SourceFlag {
+				t.Errorf("source kind = %v, want SourceFlag", src.Kind)
+			}
+			if !strings.Contains(err.Error(), tt.target) {
+				t.Errorf("error %q does not name the target %q", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	flagPath := writeConfig(t, "directEndpoints:\n  - {kind: tcp, type: public, target: \"flag.example:443\"}\n")
+	envPath := writeConfig(t, "directEndpoints:\n  - {kind: tcp, type: public, target: \"env.example:443\"}\n")
+	t.Setenv(EnvConfigPath, envPath)
+
+	cfg, src, err := Resolve(flagPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Kind != SourceFlag || cfg.DirectEndpoints[0].Target != "flag.example:443" {
+		t.Errorf("Resolve(flag) loaded %s from %v, want the -config file", cfg.DirectEndpoints[0].Target, src)
+	}
+
+	cfg, src, err = Resolve("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.Kind != SourceEnv || cfg.DirectEndpoints[0].Target != "env.example:443" {
+		t.Errorf("Resolve(\"\") loaded %s from %v, want $%s", cfg.DirectEndpoints[0].Target, src, EnvConfigPath)
+	}
+
+	if _, _, err := Resolve(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("a missing -config file must be an error, not fall back to the defaults")
+	}
+}
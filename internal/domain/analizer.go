@@ -4,12 +4,18 @@ import (
 	"time"
 )
 
-func AnalyzeConnectivity(probes []Probe, _ NetTestConfig) ConnectivityResult {
+func AnalyzeConnectivity(probes []Probe, cfg NetTestConfig) ConnectivityResult {
 	r := ConnectivityResult{
 		Probes:    probes,
 		Timestamp: time.Now(),
 	}
 	r.DetermineMode()
+
+	rules := cfg.DiagnosisRules
+	if rules == nil {
+		rules = DefaultDiagnosisRules()
+	}
+	r.Findings = withInterception(Diagnose(probes, rules), DiagnoseInterception(probes))
 	return r
 }
 
@@ -8,6 +8,10 @@ type NetTestConfig struct {
 	ProxyEndpoints  []Endpoint
 	ProxyURL        string
 	VPNIPs			[]string
+	Concurrency     int  // max parallel probes per group, 0 = not set
+	Policy          *ExecutionPolicy // nil = not set
+	DiagnosisRules  []DiagnosisRule  // nil = DefaultDiagnosisRules()
+	ICMPMode        string           // auto, native or exec; empty = not set
 }
 
 func NewNetTestConfig(
@@ -21,8 +25,10 @@ func NewNetTestConfig(
 		if ep.Type != EndpointTypeVPN {
 			return NetTestConfig{}, errors.New("all VPN endpoints must be of type VPN")
 		}
-		if ep.TargetType != TargetTypeTCP && ep.TargetType != TargetTypeICMP {
-			return NetTestConfig{}, errors.New("VPN endpoints must be TCP or ICMP")
+		switch ep.TargetType {
+		case TargetTypeTCP, TargetTypeICMP, TargetTypeUDP:
+		default:
+			return NetTestConfig{}, errors.New("VPN endpoints must be TCP, UDP or ICMP")
 		}
 	}
 
@@ -31,9 +37,9 @@ func NewNetTestConfig(
 			return NetTestConfig{}, errors.New("direct endpoints must be of type Public")
 		}
 		switch ep.TargetType {
-		case TargetTypeTCP, TargetTypeICMP, TargetTypeDNS, TargetTypeHTTP:
+		case TargetTypeTCP, TargetTypeICMP, TargetTypeDNS, TargetTypeHTTP, TargetTypeTLS, TargetTypeUDP:
 		default:
-			return NetTestConfig{}, errors.New("direct endpoints must be TCP, ICMP, DNS, or HTTP")
+			return NetTestConfig{}, errors.New("direct endpoints must be TCP, UDP, ICMP, DNS, HTTP or TLS")
 		}
 	}
 	for _, ep := range ProxyEndpoints {
@@ -68,6 +74,15 @@ func (c NetTestConfig) HasProxyChecks() bool {
 	return len(c.ProxyEndpoints) > 0
 }
 
+func (c NetTestConfig) HasTLSChecks() bool {
+	for _, ep := range c.DirectEndpoints {
+		if ep.IsTLS() {
+			return true
+		}
+	}
+	return false
+}
+
 func (c NetTestConfig) HasICMPChecks() bool {
 	for _, ep := range c.DirectEndpoints {
 		if ep.TargetType == TargetTypeICMP {
@@ -110,6 +125,14 @@ func MustNewHTTPEndpoint(url string, typ EndpointType, overProxy bool, proxyURL
 	return ep
 }
 
+func MustNewTLSEndpoint(hostPort string, typ EndpointType, serverName string, proxies []string, desc string) Endpoint {
+	ep, err := NewTLSEndpoint(hostPort, typ, serverName, proxies, desc)
+	if err != nil {
+		panic("invalid TLS endpoint: " + hostPort + " - " + err.Error())
+	}
+	return ep
+}
+
 func MustNewICMPEndpoint(host string, typ EndpointType, description string) Endpoint {
 	ep, err := NewICMPEndpoint(host, typ, description)
 	if err != nil {
@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func pass(ep Endpoint) Probe {
+	return NewSuccessfulProbe(ep, 10)
+}
+
+func fail(ep Endpoint, st Status, msg string) Probe {
+	return NewFailedProbe(ep, st, errors.New(msg))
+}
+
+func findingIDs(findings []Finding) []string {
+	ids := make([]string, len(findings))
+	for i, f := range findings {
+		ids[i] = f.RuleID
+	}
+	return ids
+}
+
+func TestDiagnoseDefaultRules(t *testing.T) {
+	var (
+		ping     = MustNewICMPEndpoint("8.8.8.8", EndpointTypePublic, "")
+		dns      = MustNewDNSEndpoint("example.com", EndpointTypePublic, "")
+		tcp443   = MustNewTCPEndpoint("example.com:443", EndpointTypePublic, "")
+		tcp22    = MustNewTCPEndpoint("example.com:22", EndpointTypePublic, "")
+		web      = MustNewHTTPEndpoint("https://example.com", EndpointTypePublic, false, "", "")
+		webProxy = MustNewHTTPEndpoint("https://example.com", EndpointTypePublic, true, "http://proxy:3128", "")
+		apiProxy = MustNewHTTPEndpoint("https://api.example.com", EndpointTypePublic, true, "http://proxy:3128", "")
+		vpnTCP   = MustNewTCPEndpoint("10.0.0.1:443", EndpointTypeVPN, "")
+	)
+
+	tests := []struct {
+		name   string
+		probes []Probe
+		want   []string
+	}{
+		{
+			name:   "everything passes",
+			probes: []Probe{pass(ping), pass(dns), pass(tcp443), pass(web)},
+			want:   []string{},
+		},
+		{
+			name:   "dns fails while ping works",
+			probes: []Probe{pass(ping), fail(dns, StatusDNSFailure, "no such host"), pass(web)},
+			want:   []string{"dns-resolver"},
+		},
+		{
+			name:   "dns fails without a ping answer",
+			probes: []Probe{fail(ping, StatusTimeout, "timeout"), fail(dns, StatusDNSFailure, "no such host"), pass(web)},
+			want:   []string{},
+		},
+		{
+			name:   "tls rejected after tcp 443 connects",
+			probes: []Probe{pass(tcp443), fail(web, StatusFail, "tls: failed to verify certificate: x509: unknown authority")},
+			want:   []string{"tls-interception"},
+		},
+		{
+			name:   "http error is not interception",
+			probes: []Probe{pass(tcp443), fail(web, StatusHTTPError, "503 Service Unavailable")},
+			want:   []string{},
+		},
+		{
+			name:   "tcp to another port does not count for interception",
+			probes: []Probe{pass(tcp22), fail(web, StatusFail, "x509: certificate signed by unknown authority")},
+			want:   []string{},
+		},
+		{
+			name:   "proxy wants credentials",
+			probes: []Probe{pass(web), fail(webProxy, StatusProxyAuth, "407 Proxy Authentication Required")},
+			want:   []string{"proxy-auth"},
+		},
+		{
+			name:   "nothing direct answers",
+			probes: []Probe{fail(ping, StatusTimeout, "timeout"), fail(tcp443, StatusTimeout, "i/o timeout"), pass(vpnTCP)},
+			want:   []string{"no-direct-internet"},
+		},
+		{
+			name:   "direct https blocked, proxy works",
+			probes: []Probe{pass(ping), fail(web, StatusConnectionRefused, "connection refused"), pass(webProxy)},
+			want:   []string{"direct-blocked-proxy-ok"},
+		},
+		{
+			name:   "ping filtered, tcp works",
+			probes: []Probe{fail(ping, StatusTimeout, "timeout"), pass(tcp443), pass(web)},
+			want:   []string{"icmp-blocked"},
+		},
+		{
+			name:   "ping binary missing",
+			probes: []Probe{fail(ping, StatusInvalidCommand, "exec: ping: not found"), pass(tcp22)},
+			want:   []string{"ping-unavailable"},
+		},
+		{
+			name: "findings are ranked by severity",
+			probes: []Probe{
+				fail(ping, StatusTimeout, "timeout"),
+				fail(dns, StatusTimeout, "timeout"),
+				fail(tcp443, StatusTimeout, "timeout"),
+				fail(web, StatusFail, "connection reset"),
+				pass(webProxy),
+				fail(apiProxy, StatusProxyAuth, "407 Proxy Authentication Required"),
+			},
+			want: []string{"proxy-auth", "no-direct-internet", "direct-blocked-proxy-ok"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findingIDs(Diagnose(tt.probes, DefaultDiagnosisRules()))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeMatcher(t *testing.T) {
+	ep := MustNewTCPEndpoint("gw.example.com:8443", EndpointTypeVPN, "")
+	p := fail(ep, StatusConnectionRefused, "dial tcp: Connection Refused")
+
+	tests := []struct {
+		name string
+		m    ProbeMatcher
+		want bool
+	}{
+		{"empty matches anything", ProbeMatcher{}, true},
+		{"kind", ProbeMatcher{Kind: "tcp"}, true},
+		{"kind is case insensitive", ProbeMatcher{Kind: "TCP"}, true},
+		{"other kind", ProbeMatcher{Kind: "icmp"}, false},
+		{"group", ProbeMatcher{Group: "vpn"}, true},
+		{"other group", ProbeMatcher{Group: "direct"}, false},
+		{"target substring", ProbeMatcher{Target: ":8443"}, true},
+		{"other target", ProbeMatcher{Target: ":443x"}, false},
+		{"fail covers every failure", ProbeMatcher{Status: "fail"}, true},
+		{"pass", ProbeMatcher{Status: "pass"}, false},
+		{"status key", ProbeMatcher{Status: StatusConnectionRefused.Key()}, true},
+		{"other status key", ProbeMatcher{Status: StatusTimeout.Key()}, false},
+		{"error contains any, case insensitive", ProbeMatcher{ErrorContains: []string{"x509", "connection refused"}}, true},
+		{"error contains none", ProbeMatcher{ErrorContains: []string{"x509"}}, false},
+		{"all fields must match", ProbeMatcher{Kind: "tcp", Group: "direct"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Matches(p); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnoseEvidence(t *testing.T) {
+	ping := MustNewICMPEndpoint("8.8.8.8", EndpointTypePublic, "")
+	dns1 := MustNewDNSEndpoint("a.example.com", EndpointTypePublic, "")
+	dns2 := MustNewDNSEndpoint("b.example.com", EndpointTypePublic, "")
+	probes := []Probe{pass(ping), fail(dns1, StatusDNSFailure, "no such host"), fail(dns2, StatusTimeout, "timeout")}
+
+	findings := Diagnose(probes, DefaultDiagnosisRules())
+	if len(findings) != 1 {
+		t.Fatalf("findings = %v, want one", findingIDs(findings))
+	}
+	var got []string
+	for _, p := range findings[0].Evidence {
+		got = append(got, p.Endpoint.Target)
+	}
+	want := []string{"8.8.8.8", "a.example.com", "b.example.com"}
+	if !slices.Equal(got, want) {
+		t.Errorf("evidence = %v, want %v", got, want)
+	}
+}
+
+func TestMergeDiagnosisRules(t *testing.T) {
+	extra := []DiagnosisRule{
+		{ID: "icmp-blocked", Severity: SeverityCritical},
+		{ID: "custom"},
+	}
+	merged := MergeDiagnosisRules(DefaultDiagnosisRules(), extra)
+
+	if n := len(merged); n != len(DefaultDiagnosisRules())+1 {
+		t.Fatalf("merged %d rules, want %d", n, len(DefaultDiagnosisRules())+1)
+	}
+	var ids []string
+	for _, r := range merged {
+		ids = append(ids, r.ID)
+	}
+	if i := slices.Index(ids, "icmp-blocked"); i < 0 || merged[i].Severity != SeverityCritical {
+		t.Errorf("icmp-blocked was not replaced by the configured rule: %v", ids)
+	}
+	if ids[len(ids)-1] != "custom" {
+		t.Errorf("configured rules must come last: %v", ids)
+	}
+}
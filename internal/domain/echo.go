@@ -0,0 +1,33 @@
+package domain
+
+// EchoReply is one ICMP echo reply. TTL is 0 when the platform does not report it.
+type EchoReply struct {
+	Seq   int
+	RTTMs float64
+	TTL   int
+}
+
+// EchoStats are the per-packet results of a native ICMP probe.
+type EchoStats struct {
+	Sent     int
+	Received int
+	Replies  []EchoReply
+}
+
+func (e EchoStats) LossPercent() float64 {
+	if e.Sent == 0 {
+		return 0
+	}
+	return float64(e.Sent-e.Received) * 100 / float64(e.Sent)
+}
+
+func (e EchoStats) AvgRTTMs() float64 {
+	if len(e.Replies) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, r := range e.Replies {
+		sum += r.RTTMs
+	}
+	return sum / float64(len(e.Replies))
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"regexp"
 	"strings"
+	"time"
 )
 
 type EndpointTargetType int
@@ -14,6 +16,8 @@ const (
 	TargetTypeTCP                            // host:port for TCP-connect
 	TargetTypeICMP                           // to ping
 	TargetTypeDNS
+	TargetTypeTLS                            // host:port for TLS handshake
+	TargetTypeUDP                            // host:port for a datagram exchange
 )
 
 func (t EndpointTargetType) String() string {
@@ -26,6 +30,10 @@ func (t EndpointTargetType) String() string {
 		return "DNS"
 	case TargetTypeICMP:
 		return "icmp"
+	case TargetTypeTLS:
+		return "tls"
+	case TargetTypeUDP:
+		return "udp"
 	default:
 		return "unknown"
 	}
@@ -54,9 +62,63 @@ type Endpoint struct {
 	TargetType    EndpointTargetType
 	Type          EndpointType
 	Proxy         ProxyConfig
+	TLS           TLSOptions
+	UDP           UDPOptions
+	Sampling      Sampling
+	Retry         RetryPolicy
+	Timeout       time.Duration		// per attempt, 0 means DefaultProbeTimeout
 	Description   string
 }
 
+// DefaultProbeTimeout bounds a single check when neither the endpoint nor its
+// kind has a timeout configured.
+const DefaultProbeTimeout = 10 * time.Second
+
+// ProbeTimeout returns the time a single check of e may take.
+func (e Endpoint) ProbeTimeout() time.Duration {
+	if e.Timeout > 0 {
+		return e.Timeout
+	}
+	return DefaultProbeTimeout
+}
+
+// TLSOptions hold the handshake parameters of a TLS endpoint and the
+// assertions on the presented chain.
+// Proxies are tried in order when the target cannot be reached directly.
+type TLSOptions struct {
+	ServerName    string
+	Proxies       []string
+	MinVersion    uint16         // crypto/tls version constant, 0 accepts the library default
+	ExpectSubject *regexp.Regexp // matched against the leaf subject, nil accepts any
+	ExpectIssuer  *regexp.Regexp // matched against the leaf issuer, nil accepts any
+	ExpiryWarning time.Duration  // warn when the leaf expires sooner, 0 means DefaultExpiryWarning
+	Pins          []string       // base64 SHA-256 public key hashes, one chain certificate must match
+
+	// Interception detection, the chain is fetched over every path and each
+	// leaf compared against these. See DetectsInterception.
+	ExpectedIssuers  []*regexp.Regexp // leaf issuer must match one
+	LeafFingerprints []string         // hex SHA-256 of the expected leaf certificates
+}
+
+// DefaultExpiryWarning is how long before the leaf expires a TLS probe turns
+// into a warning when the endpoint sets no threshold.
+const DefaultExpiryWarning = 14 * 24 * time.Hour
+
+func (o TLSOptions) ExpiryThreshold() time.Duration {
+	if o.ExpiryWarning > 0 {
+		return o.ExpiryWarning
+	}
+	return DefaultExpiryWarning
+}
+
+// UDPOptions hold the datagram sent to a UDP endpoint and the pattern its
+// response must match. Without Expect no response is required, the probe
+// only fails when the port is reported unreachable.
+type UDPOptions struct {
+	Payload []byte
+	Expect  *regexp.Regexp
+}
+
 func (e Endpoint) MustUseProxy() bool {
 	return e.TargetType == TargetTypeHTTP && e.Proxy.MustUseProxy()
 }
@@ -136,6 +198,44 @@ func NewDNSEndpoint(host string, typ EndpointType, description string) (Endpoint
 	}, nil
 }
 
+func NewTLSEndpoint(hostPort string, typ EndpointType, serverName string, proxies []string, description string) (Endpoint, error) {
+	host, _, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("invalid host:port format: %w", err)
+	}
+	if strings.TrimSpace(serverName) == "" {
+		serverName = host
+	}
+	return Endpoint{
+		Target:      hostPort,
+		TargetType:  TargetTypeTLS,
+		Type:        typ,
+		TLS:         TLSOptions{ServerName: serverName, Proxies: proxies},
+		Description: description,
+	}, nil
+}
+
+func NewUDPEndpoint(hostPort string, typ EndpointType, payload []byte, expect string, description string) (Endpoint, error) {
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		return Endpoint{}, fmt.Errorf("invalid host:port format: %w", err)
+	}
+	opts := UDPOptions{Payload: payload}
+	if expect != "" {
+		re, err := regexp.Compile(expect)
+		if err != nil {
+			return Endpoint{}, fmt.Errorf("invalid expect pattern: %w", err)
+		}
+		opts.Expect = re
+	}
+	return Endpoint{
+		Target:      hostPort,
+		TargetType:  TargetTypeUDP,
+		Type:        typ,
+		UDP:         opts,
+		Description: description,
+	}, nil
+}
+
 func (e Endpoint) GetTargetType() EndpointTargetType {
 	return e.TargetType
 }
@@ -163,3 +263,11 @@ func (e Endpoint) IsICMP() bool {
 func (e Endpoint) IsDNS() bool {
 	return e.TargetType == TargetTypeDNS
 }
+
+func (e Endpoint) IsTLS() bool {
+	return e.TargetType == TargetTypeTLS
+}
+
+func (e Endpoint) IsUDP() bool {
+	return e.TargetType == TargetTypeUDP
+}
@@ -0,0 +1,59 @@
+package domain
+
+type ProbeGroup int
+
+const (
+	GroupVPN ProbeGroup = iota
+	GroupDirect
+	GroupProxy
+)
+
+func (g ProbeGroup) String() string {
+	switch g {
+	case GroupVPN:
+		return "vpn"
+	case GroupDirect:
+		return "direct"
+	case GroupProxy:
+		return "proxy"
+	default:
+		return "unknown"
+	}
+}
+
+// Title is the section heading used by the reports.
+func (g ProbeGroup) Title() string {
+	switch g {
+	case GroupVPN:
+		return "VPN Connectivity"
+	case GroupProxy:
+		return "Internet via Proxy"
+	default:
+		return "Direct Internet"
+	}
+}
+
+func ParseProbeGroup(s string) (ProbeGroup, bool) {
+	for _, g := range []ProbeGroup{GroupVPN, GroupDirect, GroupProxy} {
+		if g.String() == s {
+			return g, true
+		}
+	}
+	return GroupDirect, false
+}
+
+// GroupProbes splits probes into the VPN, direct and proxy sections,
+// keeping their order.
+func GroupProbes(probes []Probe) (vpn, direct, proxy []Probe) {
+	for _, p := range probes {
+		switch p.Group() {
+		case GroupVPN:
+			vpn = append(vpn, p)
+		case GroupProxy:
+			proxy = append(proxy, p)
+		default:
+			direct = append(direct, p)
+		}
+	}
+	return
+}
@@ -14,8 +14,24 @@ type HostInfo struct {
 	Hostname string `string:"include"`
 	SN       string `string:"include" display:"Serial number"`
 	OS       string `string:"include" display:"Operating system"`
-	RT       string `string:"include" display:"Routing table"`
-	TLSCert  []TLSCertificate
+
+	// negotiated with the first TLS endpoint, empty without one
+	TLSVersion     string `string:"include" display:"TLS version"`
+	TLSCipherSuite string `string:"include" display:"TLS cipher suite"`
+	TLSALPN        string `string:"include" display:"TLS ALPN"`
+
+	RT      string `string:"include" display:"Routing table"`
+	TLSCert []TLSCertificate
+}
+
+// SetTLS records the chain and session of the first TLS endpoint.
+func (h *HostInfo) SetTLS(chain []TLSCertificate, session *TLSSession) {
+	h.TLSCert = chain
+	if session != nil {
+		h.TLSVersion = session.Version
+		h.TLSCipherSuite = session.CipherSuite
+		h.TLSALPN = session.ALPN
+	}
 }
 
 type NetInfo struct {
@@ -23,15 +39,35 @@ type NetInfo struct {
 }
 
 type TLSCertificate struct {
-	Subject   string    `string:"include"`
-	Issuer    string    //`string:"include"`
-	NotBefore time.Time `string:"include"`
-	NotAfter  time.Time `string:"include"`
-	Valid     bool      `string:"include"`
+	ChainIndex         int       `string:"include" display:"Chain index"` // 0 is the leaf
+	Subject            string    `string:"include"`
+	Issuer             string    `string:"include"`
+	DNSNames           []string  `string:"include" display:"DNS names"`
+	IPAddresses        []string  `string:"include" display:"IP addresses"`
+	SerialNumber       string    `string:"include" display:"Serial number"` // hex
+	NotBefore          time.Time `string:"include"`
+	NotAfter           time.Time `string:"include"`
+	Valid              bool      `string:"include"`
+	IsCA               bool      `string:"include" display:"CA"`
+	PublicKeyAlgorithm string    `string:"include" display:"Public key"` // RSA, ECDSA, Ed25519
+	PublicKeyBits      int       `string:"include" display:"Key size"`
+	SignatureAlgorithm string    `string:"include" display:"Signature"`
+	FingerprintSHA256  string    `string:"include" display:"SHA-256"` // hex SHA-256 of the DER certificate
+	SPKISHA256         string    // base64 SHA-256 of the public key, the value of a pin
+	Raw                []byte    // DER encoding as presented by the server
+}
+
+// TLSSession is what the handshake negotiated.
+type TLSSession struct {
+	Version     string // e.g. "TLS 1.3"
+	CipherSuite string // e.g. "TLS_AES_128_GCM_SHA256"
+	ALPN        string // negotiated application protocol, empty when none
 }
 
+// String lists every field, zero values included, so the leaf shows its chain
+// index and CA certificates show that they have no SANs.
 func (t TLSCertificate) String() string {
-	autostrCfg := autostr.Config{Separator: autostr.Ptr("\n"), FieldValueSeparator: autostr.Ptr(" : "), PrettyPrint: true}
+	autostrCfg := autostr.Config{Separator: autostr.Ptr("\n"), FieldValueSeparator: autostr.Ptr(" : "), PrettyPrint: true, ShowZeroValue: true}
 	return autostr.String(t, autostrCfg)
 }
 
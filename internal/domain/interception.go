@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TLSPath is the chain one network path presented for a TLS endpoint,
+// recorded when the endpoint checks for interception.
+type TLSPath struct {
+	Via          string // proxy address, empty for the direct path
+	Certificates []TLSCertificate
+	Error        string // handshake error, the path presented no chain
+	Intercepted  bool   // the leaf is not one the endpoint expects
+}
+
+func (t TLSPath) Name() string {
+	if t.Via == "" {
+		return "direct"
+	}
+	return "via " + t.Via
+}
+
+// Interceptor is the issuer of the substituted leaf, the name of the
+// inspecting CA.
+func (t TLSPath) Interceptor() string {
+	if len(t.Certificates) == 0 {
+		return ""
+	}
+	return t.Certificates[0].Issuer
+}
+
+// DetectsInterception reports whether the endpoint has an expected issuer
+// set or leaf fingerprints to compare the chain of every path against.
+func (o TLSOptions) DetectsInterception() bool {
+	return len(o.ExpectedIssuers) > 0 || len(o.LeafFingerprints) > 0
+}
+
+// IsExpectedLeaf reports whether leaf is issued by one of the expected
+// issuers and has one of the expected fingerprints, each check applies only
+// when configured.
+func (o TLSOptions) IsExpectedLeaf(leaf TLSCertificate) bool {
+	if len(o.LeafFingerprints) > 0 && !slices.Contains(o.LeafFingerprints, leaf.FingerprintSHA256) {
+		return false
+	}
+	if len(o.ExpectedIssuers) == 0 {
+		return true
+	}
+	for _, re := range o.ExpectedIssuers {
+		if re.MatchString(leaf.Issuer) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	interceptionRuleID          = "tls-interception-detected"
+	interceptionHeuristicRuleID = "tls-interception"
+)
+
+// DiagnoseInterception returns one finding per interceptor seen on the TLS
+// paths of probes, naming the issuer that replaced the expected certificates.
+func DiagnoseInterception(probes []Probe) []Finding {
+	type seen struct {
+		evidence []Probe
+		paths    []string
+	}
+	var issuers []string
+	byIssuer := make(map[string]*seen)
+	for _, p := range probes {
+		for _, path := range p.TLSPaths {
+			if !path.Intercepted {
+				continue
+			}
+			issuer := path.Interceptor()
+			s, ok := byIssuer[issuer]
+			if !ok {
+				s = &seen{}
+				byIssuer[issuer] = s
+				issuers = append(issuers, issuer)
+			}
+			if n := len(s.evidence); n == 0 || s.evidence[n-1].Endpoint.Key() != p.Endpoint.Key() {
+				s.evidence = append(s.evidence, p)
+			}
+			name := fmt.Sprintf("%s %s", p.Endpoint.Target, path.Name())
+			s.paths = append(s.paths, name)
+		}
+	}
+
+	findings := make([]Finding, 0, len(issuers))
+	for _, issuer := range issuers {
+		s := byIssuer[issuer]
+		findings = append(findings, Finding{
+			RuleID:   interceptionRuleID,
+			Title:    fmt.Sprintf("TLS interception by %q", issuer),
+			Severity: SeverityCritical,
+			Remediation: fmt.Sprintf("The certificates of %s are issued by %q and do not match the expected issuers or fingerprints. "+
+				"An SSL inspecting firewall or proxy replaces them, ask the site IT to exclude the GE endpoints from inspection.",
+				strings.Join(s.paths, ", "), issuer),
+			Evidence: s.evidence,
+		})
+	}
+	return findings
+}
+
+// withInterception puts the interception findings first and drops the
+// heuristic rule, the detected interceptor is the better explanation.
+func withInterception(findings, detected []Finding) []Finding {
+	if len(detected) == 0 {
+		return findings
+	}
+	out := slices.Clone(detected)
+	for _, f := range findings {
+		if f.RuleID != interceptionHeuristicRuleID {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIsExpectedLeaf(t *testing.T) {
+	const (
+		fpGE    = "aa11"
+		fpOther = "bb22"
+	)
+	leaf := TLSCertificate{Issuer: "CN=DigiCert Global G2 TLS RSA SHA256 2020 CA1,O=DigiCert Inc", FingerprintSHA256: fpGE}
+	zscaler := TLSCertificate{Issuer: "CN=Zscaler Intermediate Root CA,O=Zscaler Inc.", FingerprintSHA256: fpOther}
+
+	tests := []struct {
+		name string
+		opts TLSOptions
+		leaf TLSCertificate
+		want bool
+	}{
+		{name: "nothing configured", leaf: zscaler, want: true},
+		{name: "issuer matches", opts: TLSOptions{ExpectedIssuers: []*regexp.Regexp{regexp.MustCompile("DigiCert")}}, leaf: leaf, want: true},
+		{name: "issuer mismatch", opts: TLSOptions{ExpectedIssuers: []*regexp.Regexp{regexp.MustCompile("DigiCert")}}, leaf: zscaler, want: false},
+		{
+			name: "second issuer matches",
+			opts: TLSOptions{ExpectedIssuers: []*regexp.Regexp{regexp.MustCompile("Let's Encrypt"), regexp.MustCompile("DigiCert")}},
+			leaf: leaf,
+			want: true,
+		},
+		{name: "fingerprint matches", opts: TLSOptions{LeafFingerprints: []string{fpOther, fpGE}}, leaf: leaf, want: true},
+		{name: "fingerprint mismatch", opts: TLSOptions{LeafFingerprints: []string{fpGE}}, leaf: zscaler, want: false},
+		{
+			name: "issuer matches but fingerprint does not",
+			opts: TLSOptions{ExpectedIssuers: []*regexp.Regexp{regexp.MustCompile("DigiCert")}, LeafFingerprints: []string{fpOther}},
+			leaf: leaf,
+			want: false,
+		},
+		{
+			name: "fingerprint matches but issuer does not",
+			opts: TLSOptions{ExpectedIssuers: []*regexp.Regexp{regexp.MustCompile("Zscaler")}, LeafFingerprints: []string{fpGE}},
+			leaf: leaf,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.IsExpectedLeaf(tt.leaf); got != tt.want {
+				t.Errorf("IsExpectedLeaf = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnoseInterception(t *testing.T) {
+	api := MustNewTLSEndpoint("api.example.com:443", EndpointTypePublic, "", []string{"proxy:3128"}, "")
+	web := MustNewTLSEndpoint("www.example.com:443", EndpointTypePublic, "", []string{"proxy:3128"}, "")
+	zscaler := []TLSCertificate{{Subject: "CN=api.example.com", Issuer: "CN=Zscaler Root CA"}}
+	digicert := []TLSCertificate{{Subject: "CN=api.example.com", Issuer: "CN=DigiCert CA"}}
+
+	intercepted := func(ep Endpoint) Probe {
+		p := fail(ep, StatusFail, "TLS interception")
+		p.TLSPaths = []TLSPath{
+			{Certificates: digicert},
+			{Via: "proxy:3128", Certificates: zscaler, Intercepted: true},
+		}
+		return p
+	}
+	clean := pass(web)
+	clean.TLSPaths = []TLSPath{{Certificates: digicert}, {Via: "proxy:3128", Certificates: digicert}}
+
+	findings := DiagnoseInterception([]Probe{intercepted(api), clean, intercepted(web)})
+	if len(findings) != 1 {
+		t.Fatalf("findings = %v, want one per interceptor", findingIDs(findings))
+	}
+	f := findings[0]
+	if f.RuleID != interceptionRuleID || !strings.Contains(f.Title, "Zscaler Root CA") {
+		t.Errorf("finding = %s %q, want %s naming the interceptor", f.RuleID, f.Title, interceptionRuleID)
+	}
+	for _, want := range []string{"api.example.com:443 via proxy:3128", "www.example.com:443 via proxy:3128"} {
+		if !strings.Contains(f.Remediation, want) {
+			t.Errorf("remediation %q does not name %q", f.Remediation, want)
+		}
+	}
+	if len(f.Evidence) != 2 {
+		t.Errorf("evidence = %d probes, want the 2 intercepted ones", len(f.Evidence))
+	}
+
+	if got := DiagnoseInterception([]Probe{clean}); len(got) != 0 {
+		t.Errorf("findings without an intercepted path = %v, want none", findingIDs(got))
+	}
+}
+
+func TestInterceptionReplacesHeuristic(t *testing.T) {
+	var (
+		tcp443 = MustNewTCPEndpoint("example.com:443", EndpointTypePublic, "")
+		web    = MustNewHTTPEndpoint("https://example.com", EndpointTypePublic, false, "", "")
+		api    = MustNewTLSEndpoint("api.example.com:443", EndpointTypePublic, "", nil, "")
+	)
+	heuristic := []Probe{pass(tcp443), fail(web, StatusFail, "tls: failed to verify certificate: x509: unknown authority")}
+	interceptedAPI := fail(api, StatusFail, "TLS interception")
+	interceptedAPI.TLSPaths = []TLSPath{{Certificates: []TLSCertificate{{Issuer: "CN=Zscaler Root CA"}}, Intercepted: true}}
+
+	tests := []struct {
+		name   string
+		probes []Probe
+		want   []string
+	}{
+		{name: "heuristic only", probes: heuristic, want: []string{"tls-interception"}},
+		{
+			name:   "detected interception replaces the heuristic",
+			probes: append(slices.Clone(heuristic), interceptedAPI),
+			want:   []string{"tls-interception-detected"},
+		},
+		{
+			name:   "other findings are kept after it",
+			probes: []Probe{interceptedAPI},
+			want:   []string{"tls-interception-detected", "no-direct-internet"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findingIDs(AnalyzeConnectivity(tt.probes, NetTestConfig{}).Findings)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
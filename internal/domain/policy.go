@@ -1,5 +1,7 @@
 package domain
 
+import "strings"
+
 type ExecutionPolicy int
 
 const (
@@ -28,9 +30,18 @@ func (e ExecutionPolicy) String() string {
 	return "Unknown"
 }
 
+// ParseExecutionPolicy accepts the policy name in any letter case,
+// e.g. "optimized" or "Exhaustive".
 func ParseExecutionPolicy(s string) (ExecutionPolicy, bool) {
-	policy, exists := stringToPolicy[s]
-	return policy, exists
+	if policy, exists := stringToPolicy[s]; exists {
+		return policy, true
+	}
+	for str, policy := range stringToPolicy {
+		if strings.EqualFold(str, strings.TrimSpace(s)) {
+			return policy, true
+		}
+	}
+	return PolicyOptimized, false
 }
 
 func ParseExecutionPolicyWithDefault(s string) ExecutionPolicy {
@@ -6,15 +6,36 @@ import (
 )
 
 type Probe struct {
-	Endpoint  Endpoint
-	Status    Status
-	LatencyMs float64
-	Error     string
-	Timestamp time.Time
+	Endpoint     Endpoint
+	Status       Status
+	LatencyMs    float64
+	Error        string
+	Timestamp    time.Time
+	Certificates []TLSCertificate // peer chain, TLS probes only
+	TLSSession   *TLSSession      // negotiated parameters, TLS probes only
+	TLSPaths     []TLSPath        // chain per path, TLS probes detecting interception only
+	Echo         *EchoStats       // per-packet results, native ICMP probes only
+	Stats        *ProbeStats      // aggregated samples, multi-sample endpoints only
+	Attempts     []Attempt        // every try in order, endpoints with a retry policy only
 }
 
+// IsSuccessful reports whether the endpoint was reachable.
+// A warning still counts as reachable.
 func (p Probe) IsSuccessful() bool {
-	return p.Status == StatusPass
+	return p.Status.IsSuccess()
+}
+
+func (p Probe) IsWarning() bool {
+	return p.Status == StatusWarning
+}
+
+// PassedOnRetry returns the number of the retry that succeeded after the
+// first attempt failed, 0 for a clean pass or a failure.
+func (p Probe) PassedOnRetry() int {
+	if !p.IsSuccessful() || len(p.Attempts) < 2 {
+		return 0
+	}
+	return len(p.Attempts) - 1
 }
 
 func (p Probe) IsSkipped() bool {
@@ -29,6 +50,18 @@ func (p Probe) IsDNSProbe() bool {
 	return p.Endpoint.IsDNS()
 }
 
+// Group returns the report section the probe belongs to.
+func (p Probe) Group() ProbeGroup {
+	switch {
+	case p.Endpoint.Type == EndpointTypeVPN:
+		return GroupVPN
+	case p.Endpoint.Proxy.MustUseProxy():
+		return GroupProxy
+	default:
+		return GroupDirect
+	}
+}
+
 func (p Probe) String() string {
 	var str string
 	if !p.IsSuccessful() {
@@ -54,6 +87,12 @@ func NewFailedProbe(endpoint Endpoint, status Status, err error) Probe {
 	return *p 
 }
 
+func NewSkippedProbe(endpoint Endpoint, reason string) Probe {
+	p := NewProbe(endpoint)
+	p.MarkSkipped(reason)
+	return *p
+}
+
 func NewProbe(endpoint Endpoint) *Probe{
 	return &Probe{Endpoint: endpoint}
 }
@@ -65,12 +104,32 @@ func (p *Probe) MarkSuccess(latMs float64) {
 	p.Timestamp = time.Now()
 }
 
+// MarkWarning records a reachable endpoint with a problem worth reporting.
+func (p *Probe) MarkWarning(latMs float64, reason error) {
+	p.Status = StatusWarning
+	p.LatencyMs = latMs
+	p.Error = safeErr(reason)
+	p.Timestamp = time.Now()
+}
+
+// MarkFailure records a failed probe with its classified status.
+// Statuses that do not describe a failure are recorded as StatusFail.
 func (p *Probe) MarkFailure(st Status, err error) {
-	p.Status = StatusFail
+	if !st.IsFailure() {
+		st = StatusFail
+	}
+	p.Status = st
 	p.Error = safeErr(err)
 	p.Timestamp = time.Now()
 }
 
+// MarkSkipped records an endpoint that was deliberately not probed.
+func (p *Probe) MarkSkipped(reason string) {
+	p.Status = StatusSkipped
+	p.Error = reason
+	p.Timestamp = time.Now()
+}
+
 func safeErr(err error) string {
 	if err == nil { return "" }
 	return err.Error()
@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
 
 type ConnectivityResult struct {
 	Mode        ConnectivityMode
@@ -8,6 +12,7 @@ type ConnectivityResult struct {
 	Probes      []Probe
 	Timestamp   time.Time
 	Summary     string
+	Findings    []Finding
 }
 
 func NewConnectivityResult(mode ConnectivityMode, probes []Probe) ConnectivityResult {
@@ -32,16 +37,27 @@ func (r ConnectivityResult) SuccessfulProbes() []Probe {
 	return success
 }
 
+// FailedProbes returns probes that ran and did not succeed, skipped ones are excluded.
 func (r ConnectivityResult) FailedProbes() []Probe {
 	var failed []Probe
 	for _, p := range r.Probes {
-		if !p.IsSuccessful() {
+		if !p.IsSuccessful() && !p.IsSkipped() {
 			failed = append(failed, p)
 		}
 	}
 	return failed
 }
 
+func (r ConnectivityResult) SkippedProbes() []Probe {
+	var skipped []Probe
+	for _, p := range r.Probes {
+		if p.IsSkipped() {
+			skipped = append(skipped, p)
+		}
+	}
+	return skipped
+}
+
 func (r *ConnectivityResult) DetermineMode() {
 	var (
 		vpnOK, directOK, proxyOK, dnsOK bool
@@ -61,7 +77,11 @@ func (r *ConnectivityResult) DetermineMode() {
 
 		switch {
 		case p.Endpoint.IsVPN():
-			vpnOK = true
+			// a UDP probe without an expected response also passes when
+			// nothing listens, it does not prove the tunnel is up
+			if !p.Endpoint.IsUDP() || p.Endpoint.UDP.Expect != nil {
+				vpnOK = true
+			}
 
 		case !p.Endpoint.IsPublic():
 			continue
@@ -100,4 +120,64 @@ func buildSummary(mode ConnectivityMode) string {
 	default:
 		return "No connection"
 	}
-}
\ No newline at end of file
+}
+
+// StatusCounts returns how many probes ended with each status.
+func (r ConnectivityResult) StatusCounts() map[Status]int {
+	counts := make(map[Status]int)
+	for _, p := range r.Probes {
+		counts[p.Status]++
+	}
+	return counts
+}
+
+// IssueSummary describes the probes that did not pass, most frequent first,
+// e.g. "3 timeouts, 1 DNS failure". It is empty when every probe passed.
+func (r ConnectivityResult) IssueSummary() string {
+	counts := r.StatusCounts()
+	statuses := make([]Status, 0, len(counts))
+	for st := range counts {
+		if st != StatusPass {
+			statuses = append(statuses, st)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if counts[statuses[i]] != counts[statuses[j]] {
+			return counts[statuses[i]] > counts[statuses[j]]
+		}
+		return statuses[i] < statuses[j]
+	})
+
+	parts := make([]string, 0, len(statuses))
+	for _, st := range statuses {
+		parts = append(parts, st.CountLabel(counts[st]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CertificateChain returns the chain of the first TLS probe that got one,
+// it is reported as the host's TLS certificates.
+func (r ConnectivityResult) CertificateChain() []TLSCertificate {
+	if p, ok := r.firstTLSProbe(); ok {
+		return p.Certificates
+	}
+	return NewTLSCertificate()
+}
+
+// TLSSession returns the session negotiated by the probe CertificateChain
+// reports, nil when there is none.
+func (r ConnectivityResult) TLSSession() *TLSSession {
+	if p, ok := r.firstTLSProbe(); ok {
+		return p.TLSSession
+	}
+	return nil
+}
+
+func (r ConnectivityResult) firstTLSProbe() (Probe, bool) {
+	for _, p := range r.Probes {
+		if p.Endpoint.IsTLS() && len(p.Certificates) > 0 {
+			return p, true
+		}
+	}
+	return Probe{}, false
+}
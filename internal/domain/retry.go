@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// RetryPolicy repeats a failed check when the failure looks transient.
+// The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first, values below 2 disable retries
+	Backoff     time.Duration // delay before the second attempt, doubled for every further attempt
+	MaxBackoff  time.Duration // upper bound for the delay, 0 means unbounded
+}
+
+func (r RetryPolicy) Enabled() bool {
+	return r.MaxAttempts > 1
+}
+
+// Retryable reports whether a probe with status st is worth another attempt.
+// Only timeouts and refused connections are considered transient.
+func (r RetryPolicy) Retryable(st Status) bool {
+	return st == StatusTimeout || st == StatusConnectionRefused
+}
+
+// Delay returns the pause before attempt n, n starting at 2.
+func (r RetryPolicy) Delay(n int) time.Duration {
+	d := r.Backoff
+	for i := 2; i < n && d > 0; i++ {
+		d *= 2
+		if r.MaxBackoff > 0 && d >= r.MaxBackoff {
+			break
+		}
+	}
+	if r.MaxBackoff > 0 && d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+	return d
+}
+
+// Attempt is the outcome of one try of a retried probe.
+type Attempt struct {
+	Status    Status
+	LatencyMs float64
+	Error     string
+	Timestamp time.Time
+}
+
+func NewAttempt(p Probe) Attempt {
+	return Attempt{Status: p.Status, LatencyMs: p.LatencyMs, Error: p.Error, Timestamp: p.Timestamp}
+}
@@ -22,6 +22,14 @@ type ICMPChecker interface {
 	CheckPingWithContext(ctx context.Context, ep Endpoint) Probe
 }
 
+type TLSChecker interface {
+	CheckTLSWithContext(ctx context.Context, ep Endpoint) Probe
+}
+
+type UDPChecker interface {
+	CheckUDPWithContext(ctx context.Context, ep Endpoint) Probe
+}
+
 type HostChecker interface {
 	GetCRMInfo(ctx context.Context) HostInfo
 }
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Sampling controls how often an endpoint is probed in one run and when the
+// aggregated result is downgraded to a warning.
+type Sampling struct {
+	Count          int           // samples per run, values below 2 mean a single attempt
+	Interval       time.Duration // pause between samples
+	MaxLossPercent float64       // warn when loss is above this value
+	MaxJitterMs    float64       // warn when jitter is above this value, 0 disables the check
+}
+
+// DefaultMaxLossPercent is the loss limit of sampling configs that do not set one.
+const DefaultMaxLossPercent = 20
+
+func (s Sampling) IsMulti() bool {
+	return s.Count > 1
+}
+
+// ProbeStats aggregates the samples of one endpoint.
+// Latency figures only cover successful samples.
+type ProbeStats struct {
+	Samples     int
+	Received    int
+	MinMs       float64
+	AvgMs       float64
+	MaxMs       float64
+	StdDevMs    float64
+	JitterMs    float64 // mean difference between consecutive latencies
+	LossPercent float64
+}
+
+// NewProbeStats builds stats for samples attempts of which latencies succeeded,
+// latencies are expected in the order they were measured.
+func NewProbeStats(samples int, latencies []float64) ProbeStats {
+	st := ProbeStats{Samples: samples, Received: len(latencies)}
+	if samples > 0 {
+		st.LossPercent = float64(samples-len(latencies)) * 100 / float64(samples)
+	}
+	if len(latencies) == 0 {
+		return st
+	}
+
+	st.MinMs, st.MaxMs = latencies[0], latencies[0]
+	var sum float64
+	for _, l := range latencies {
+		sum += l
+		st.MinMs = math.Min(st.MinMs, l)
+		st.MaxMs = math.Max(st.MaxMs, l)
+	}
+	st.AvgMs = sum / float64(len(latencies))
+
+	var sq, diff float64
+	for i, l := range latencies {
+		sq += (l - st.AvgMs) * (l - st.AvgMs)
+		if i > 0 {
+			diff += math.Abs(l - latencies[i-1])
+		}
+	}
+	st.StdDevMs = math.Sqrt(sq / float64(len(latencies)))
+	if len(latencies) > 1 {
+		st.JitterMs = diff / float64(len(latencies)-1)
+	}
+	return st
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewProbeStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		samples   int
+		latencies []float64
+		want      ProbeStats
+	}{
+		{
+			name:    "nothing received",
+			samples: 4,
+			want:    ProbeStats{Samples: 4, LossPercent: 100},
+		},
+		{
+			name:      "all received",
+			samples:   4,
+			latencies: []float64{10, 20, 10, 20},
+			want:      ProbeStats{Samples: 4, Received: 4, MinMs: 10, AvgMs: 15, MaxMs: 20, StdDevMs: 5, JitterMs: 10},
+		},
+		{
+			name:      "constant latency has no jitter",
+			samples:   3,
+			latencies: []float64{7, 7, 7},
+			want:      ProbeStats{Samples: 3, Received: 3, MinMs: 7, AvgMs: 7, MaxMs: 7},
+		},
+		{
+			name:      "partial loss",
+			samples:   5,
+			latencies: []float64{30, 10, 20},
+			want:      ProbeStats{Samples: 5, Received: 3, MinMs: 10, AvgMs: 20, MaxMs: 30, StdDevMs: math.Sqrt(200.0 / 3), JitterMs: 15, LossPercent: 40},
+		},
+		{
+			name:      "single sample",
+			samples:   1,
+			latencies: []float64{12},
+			want:      ProbeStats{Samples: 1, Received: 1, MinMs: 12, AvgMs: 12, MaxMs: 12},
+		},
+		{
+			name: "no samples",
+			want: ProbeStats{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewProbeStats(tt.samples, tt.latencies)
+			if got.Samples != tt.want.Samples || got.Received != tt.want.Received {
+				t.Errorf("samples/received = %d/%d, want %d/%d", got.Samples, got.Received, tt.want.Samples, tt.want.Received)
+			}
+			for _, f := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"min", got.MinMs, tt.want.MinMs},
+				{"avg", got.AvgMs, tt.want.AvgMs},
+				{"max", got.MaxMs, tt.want.MaxMs},
+				{"stddev", got.StdDevMs, tt.want.StdDevMs},
+				{"jitter", got.JitterMs, tt.want.JitterMs},
+				{"loss", got.LossPercent, tt.want.LossPercent},
+			} {
+				if math.Abs(f.got-f.want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "fmt"
+
 type Status int
 
 const (
@@ -20,7 +22,7 @@ const (
 func (s Status) IsValid() bool {
 	switch s {
 	case StatusUnknown, StatusSkipped, StatusFail, StatusPass, StatusWarning, StatusTimeout,
-		StatusConnectionRefused, StatusInvalid, StatusDNSFailure, StatusHTTPError, StatusProxyAuth:
+		StatusConnectionRefused, StatusInvalid, StatusInvalidCommand, StatusDNSFailure, StatusHTTPError, StatusProxyAuth:
 		return true
 	}
 	return false
@@ -48,7 +50,7 @@ func (s Status) String() string {
 	case StatusInvalid:
 		return "Invalid"
 	case StatusInvalidCommand:
-		return "Comand is invalid"
+		return "Invalid command"
 	case StatusDNSFailure:
 		return "DNS Failure"
 	case StatusHTTPError:
@@ -66,3 +68,75 @@ func (s Status) IsSuccess() bool {
 func (s Status) IsTerminal() bool {
 	return s != StatusUnknown
 }
+
+var statusKeys = map[Status]string{
+	StatusUnknown:           "unknown",
+	StatusSkipped:           "skipped",
+	StatusFail:              "fail",
+	StatusPass:              "pass",
+	StatusWarning:           "warning",
+	StatusTimeout:           "timeout",
+	StatusConnectionRefused: "connection_refused",
+	StatusInvalid:           "invalid",
+	StatusInvalidCommand:    "invalid_command",
+	StatusDNSFailure:        "dns_failure",
+	StatusHTTPError:         "http_error",
+	StatusProxyAuth:         "proxy_auth",
+}
+
+// Key returns a stable, machine friendly name for the status, used in
+// config files and machine readable output. String() is meant for humans.
+func (s Status) Key() string {
+	if k, ok := statusKeys[s]; ok {
+		return k
+	}
+	return "unknown"
+}
+
+func ParseStatusKey(key string) (Status, bool) {
+	for st, k := range statusKeys {
+		if k == key {
+			return st, true
+		}
+	}
+	return StatusUnknown, false
+}
+
+// IsFailure reports whether s is one of the failure statuses.
+func (s Status) IsFailure() bool {
+	return s.IsValid() && !s.IsSuccess() && s != StatusSkipped && s != StatusUnknown
+}
+
+// CountLabel returns a lower case noun for n probes with this status,
+// e.g. "1 timeout" or "3 DNS failures".
+func (s Status) CountLabel(n int) string {
+	one, many := "unknown", "unknown"
+	switch s {
+	case StatusSkipped:
+		one, many = "skipped", "skipped"
+	case StatusFail:
+		one, many = "failure", "failures"
+	case StatusPass:
+		one, many = "passed", "passed"
+	case StatusWarning:
+		one, many = "warning", "warnings"
+	case StatusTimeout:
+		one, many = "timeout", "timeouts"
+	case StatusConnectionRefused:
+		one, many = "connection refused", "connections refused"
+	case StatusInvalid:
+		one, many = "invalid result", "invalid results"
+	case StatusInvalidCommand:
+		one, many = "invalid command", "invalid commands"
+	case StatusDNSFailure:
+		one, many = "DNS failure", "DNS failures"
+	case StatusHTTPError:
+		one, many = "HTTP error", "HTTP errors"
+	case StatusProxyAuth:
+		one, many = "proxy auth error", "proxy auth errors"
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
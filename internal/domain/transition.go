@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+type TransitionKind int
+
+const (
+	TransitionMode TransitionKind = iota
+	TransitionEndpoint
+)
+
+// Transition is a change between two consecutive runs: the connectivity mode
+// changed, or an endpoint flipped between reachable and failing.
+type Transition struct {
+	Kind     TransitionKind
+	Time     time.Time
+	FromMode ConnectivityMode
+	ToMode   ConnectivityMode
+	Probe    Probe // current probe, endpoint transitions only
+	From     Status
+	To       Status
+}
+
+func (t Transition) String() string {
+	if t.Kind == TransitionMode {
+		return fmt.Sprintf("mode %s -> %s", t.FromMode, t.ToMode)
+	}
+	name := t.Probe.Endpoint.Description
+	if name == "" {
+		name = t.Probe.Endpoint.Target
+	}
+	str := fmt.Sprintf("%s %s: %s -> %s", t.Probe.Endpoint.TargetType, name, t.From, t.To)
+	if t.Probe.Error != "" {
+		str += " (" + t.Probe.Error + ")"
+	}
+	return str
+}
+
+// CompareResults lists the transitions from prev to cur. Endpoints are matched
+// by Endpoint.Key, warnings count as reachable and skipped probes are ignored.
+func CompareResults(prev, cur ConnectivityResult) []Transition {
+	var out []Transition
+	if prev.Mode != cur.Mode {
+		out = append(out, Transition{
+			Kind:     TransitionMode,
+			Time:     cur.Timestamp,
+			FromMode: prev.Mode,
+			ToMode:   cur.Mode,
+		})
+	}
+
+	before := make(map[string]Probe, len(prev.Probes))
+	for _, p := range prev.Probes {
+		before[p.Endpoint.Key()] = p
+	}
+	for _, p := range cur.Probes {
+		old, ok := before[p.Endpoint.Key()]
+		if !ok || old.IsSkipped() || p.IsSkipped() || old.IsSuccessful() == p.IsSuccessful() {
+			continue
+		}
+		out = append(out, Transition{
+			Kind:  TransitionEndpoint,
+			Time:  p.Timestamp,
+			Probe: p,
+			From:  old.Status,
+			To:    p.Status,
+		})
+	}
+	return out
+}
+
+// EndpointUptime counts how often an endpoint was reachable over several runs.
+type EndpointUptime struct {
+	Endpoint Endpoint
+	Runs     int // runs in which the endpoint was probed, skipped ones excluded
+	Passed   int
+}
+
+func (u EndpointUptime) Percent() float64 {
+	if u.Runs == 0 {
+		return 0
+	}
+	return float64(u.Passed) * 100 / float64(u.Runs)
+}
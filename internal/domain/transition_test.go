@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompareResults(t *testing.T) {
+	ep := MustNewTCPEndpoint("example.com:443", EndpointTypePublic, "")
+	up := NewSuccessfulProbe(ep, 10)
+	warn := up
+	warn.Status = StatusWarning
+	down := NewFailedProbe(ep, StatusTimeout, errors.New("i/o timeout"))
+	skipped := NewSkippedProbe(ep, "no ICMP endpoint of this group answered")
+
+	result := func(mode ConnectivityMode, probes ...Probe) ConnectivityResult {
+		return ConnectivityResult{Mode: mode, IsConnected: mode != ModeNone, Probes: probes}
+	}
+
+	tests := []struct {
+		name      string
+		prev, cur ConnectivityResult
+		want      []Transition
+	}{
+		{
+			name: "unchanged",
+			prev: result(ModeDirect, up),
+			cur:  result(ModeDirect, up),
+		},
+		{
+			name: "pass to fail",
+			prev: result(ModeDirect, up),
+			cur:  result(ModeNone, down),
+			want: []Transition{
+				{Kind: TransitionMode, FromMode: ModeDirect, ToMode: ModeNone},
+				{Kind: TransitionEndpoint, Probe: down, From: StatusPass, To: StatusTimeout},
+			},
+		},
+		{
+			name: "fail to pass",
+			prev: result(ModeNone, down),
+			cur:  result(ModeNone, up),
+			want: []Transition{
+				{Kind: TransitionEndpoint, Probe: up, From: StatusTimeout, To: StatusPass},
+			},
+		},
+		{
+			name: "warning counts as reachable",
+			prev: result(ModeDirect, up),
+			cur:  result(ModeDirect, warn),
+		},
+		{
+			name: "skipped probes are ignored",
+			prev: result(ModeDirect, up),
+			cur:  result(ModeDirect, skipped),
+		},
+		{
+			name: "new endpoint",
+			prev: result(ModeDirect),
+			cur:  result(ModeDirect, down),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareResults(tt.prev, tt.cur)
+			if len(got) != len(tt.want) {
+				t.Fatalf("transitions = %v, want %v", got, tt.want)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Kind != w.Kind || g.FromMode != w.FromMode || g.ToMode != w.ToMode ||
+					g.From != w.From || g.To != w.To || g.Probe.Endpoint.Key() != w.Probe.Endpoint.Key() {
+					t.Errorf("transition %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointUptimePercent(t *testing.T) {
+	tests := []struct {
+		runs, passed int
+		want         float64
+	}{
+		{0, 0, 0},
+		{4, 4, 100},
+		{4, 3, 75},
+		{3, 1, 100.0 / 3},
+	}
+	for _, tt := range tests {
+		u := EndpointUptime{Runs: tt.runs, Passed: tt.passed}
+		if got := u.Percent(); got != tt.want {
+			t.Errorf("Percent() of %d/%d = %v, want %v", tt.passed, tt.runs, got, tt.want)
+		}
+	}
+}